@@ -0,0 +1,364 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrUnknownField is returned by a RecordBuilder when the template has
+	// no field with the given name or ID.
+	ErrUnknownField = errors.New("field not in template")
+
+	// ErrFieldType is returned by a RecordBuilder when a value cannot be
+	// encoded as the type of the field.
+	ErrFieldType = errors.New("value does not match field type")
+
+	// ErrFieldValue is returned by a RecordBuilder when a value does not fit
+	// the length of the field in the template.
+	ErrFieldValue = errors.New("value does not fit field length")
+
+	// ErrFieldUnset is returned by RecordBuilder.Record when a field of the
+	// template has not been set.
+	ErrFieldUnset = errors.New("field not set")
+)
+
+// A RecordBuilder encodes Go values into a DataRecord for a template, using
+// the field types of the dictionary of an Interpreter. It is the inverse of
+// Interpret.
+//
+// Integers of any Go type are accepted for the integer fields and encoded in
+// the length of the template field, which may be shorter than the type
+// (reduced-size encoding). Float64 fields of length 4 are encoded as
+// float32. Time fields take a time.Time, address fields a net.IP,
+// net.HardwareAddr or netip.Addr, boolean fields a bool, and string and
+// octetArray fields a string or []byte. Variable-length fields take strings
+// and byte slices of any length. A []byte of the right length is accepted
+// for any field and copied as is.
+type RecordBuilder struct {
+	templateID uint16
+	tpl        []TemplateFieldSpecifier
+	entries    []DictionaryEntry // dictionary entries of the template fields, Type is Unknown if not found
+	fields     [][]byte
+	unixTimes  bool
+}
+
+// NewRecordBuilder returns a RecordBuilder for records of the template with
+// the given ID and field specifiers. For options templates, the field
+// specifiers hold the scope fields followed by the option fields.
+func (i *Interpreter) NewRecordBuilder(templateID uint16, tpl []TemplateFieldSpecifier) *RecordBuilder {
+	b := &RecordBuilder{
+		templateID: templateID,
+		tpl:        tpl,
+		entries:    make([]DictionaryEntry, len(tpl)),
+		fields:     make([][]byte, len(tpl)),
+		unixTimes:  i.unixTimes,
+	}
+	dict := i.Dictionary()
+	for j, field := range tpl {
+		b.entries[j], _ = dict.lookup(dictionaryKey{field.EnterpriseID, field.FieldID})
+	}
+	return b
+}
+
+// Set encodes the value of the first field of the template with the given
+// dictionary name.
+func (b *RecordBuilder) Set(name string, value interface{}) error {
+	for j := range b.tpl {
+		if b.entries[j].Name == name {
+			return b.SetIndex(j, value)
+		}
+	}
+	return fmt.Errorf("%s: %w", name, ErrUnknownField)
+}
+
+// SetField encodes the value of the first field of the template with the
+// given enterprise and field ID.
+func (b *RecordBuilder) SetField(enterpriseID uint32, fieldID uint16, value interface{}) error {
+	for j, field := range b.tpl {
+		if field.EnterpriseID == enterpriseID && field.FieldID == fieldID {
+			return b.SetIndex(j, value)
+		}
+	}
+	return fmt.Errorf("%d.%d: %w", enterpriseID, fieldID, ErrUnknownField)
+}
+
+// SetIndex encodes the value of the field at the given index in the
+// template.
+func (b *RecordBuilder) SetIndex(index int, value interface{}) error {
+	if index < 0 || index >= len(b.tpl) {
+		return fmt.Errorf("field %d: %w", index, ErrUnknownField)
+	}
+	bs, err := encodeValue(value, b.entries[index].Type, b.tpl[index].Length, b.unixTimes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", b.fieldName(index), err)
+	}
+	b.fields[index] = bs
+	return nil
+}
+
+func (b *RecordBuilder) fieldName(index int) string {
+	if name := b.entries[index].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("%d.%d", b.tpl[index].EnterpriseID, b.tpl[index].FieldID)
+}
+
+// Record returns the record built so far and resets the builder for the
+// next record. It fails if any field of the template has not been set.
+func (b *RecordBuilder) Record() (DataRecord, error) {
+	for j, field := range b.fields {
+		if field == nil {
+			return DataRecord{}, fmt.Errorf("%s: %w", b.fieldName(j), ErrFieldUnset)
+		}
+	}
+	dr := DataRecord{TemplateID: b.templateID, Fields: b.fields}
+	b.fields = make([][]byte, len(b.tpl))
+	return dr, nil
+}
+
+// naturalLength is the encoded length of a full size value of the given
+// type, or 0 for types without a fixed length.
+func (t FieldType) naturalLength() int {
+	switch t {
+	case Uint8, Int8, Boolean:
+		return 1
+	case Uint16, Int16:
+		return 2
+	case Uint24:
+		return 3
+	case Uint32, Int32, Float32, DateTimeSeconds, Ipv4Address:
+		return 4
+	case Uint64, Int64, Float64, VarInt, DateTimeMilliseconds, DateTimeMicroseconds, DateTimeNanoseconds:
+		return 8
+	case MacAddress:
+		return 6
+	case Ipv6Address:
+		return 16
+	default:
+		return 0
+	}
+}
+
+// ipAddr is implemented by netip.Addr.
+type ipAddr interface {
+	AsSlice() []byte
+}
+
+// encodeValue encodes a value as the given type, in a field of the given
+// template length. If unixTimes is set, dateTimeMicroseconds and
+// dateTimeNanoseconds are encoded as counts since the Unix epoch rather than
+// as NTP timestamps, see WithUnixTimes.
+func encodeValue(value interface{}, t FieldType, length uint16, unixTimes bool) ([]byte, error) {
+	variable := length == 0xffff
+	if bs, ok := value.([]byte); ok {
+		switch {
+		case variable:
+		case t == String && len(bs) <= int(length):
+			return padded(bs, length), nil
+		case len(bs) != int(length):
+			return nil, ErrFieldValue
+		}
+		return append([]byte{}, bs...), nil
+	}
+
+	if n := t.naturalLength(); n > 0 && (variable || int(length) > n || int(length) < t.minLength()) {
+		// Reduced-size encoding only ever makes fields shorter
+		return nil, ErrFieldValue
+	}
+
+	switch t {
+	case Uint8, Uint16, Uint24, Uint32, Uint64, VarInt:
+		u, ok := unsignedValue(value)
+		if !ok {
+			if _, ok := signedValue(value); ok {
+				return nil, ErrFieldValue // negative
+			}
+			return nil, ErrFieldType
+		}
+		if length < 8 && u>>(8*length) != 0 {
+			return nil, ErrFieldValue
+		}
+		return putNumber(u, length), nil
+
+	case Int8, Int16, Int32, Int64:
+		v, ok := signedValue(value)
+		if !ok {
+			return nil, ErrFieldType
+		}
+		if bits := 8 * length; bits < 64 && (v < -1<<(bits-1) || v >= 1<<(bits-1)) {
+			return nil, ErrFieldValue
+		}
+		return putNumber(uint64(v), length), nil
+
+	case Float32, Float64:
+		var f float64
+		switch v := value.(type) {
+		case float32:
+			f = float64(v)
+		case float64:
+			f = v
+		default:
+			return nil, ErrFieldType
+		}
+		if length == 4 {
+			return putNumber(uint64(math.Float32bits(float32(f))), 4), nil
+		}
+		return putNumber(math.Float64bits(f), 8), nil
+
+	case Boolean:
+		v, ok := value.(bool)
+		if !ok {
+			return nil, ErrFieldType
+		}
+		// RFC 7011 section 6.1.5
+		if v {
+			return []byte{1}, nil
+		}
+		return []byte{2}, nil
+
+	case MacAddress:
+		v, ok := value.(net.HardwareAddr)
+		if !ok {
+			return nil, ErrFieldType
+		}
+		if len(v) != 6 {
+			return nil, ErrFieldValue
+		}
+		return append([]byte{}, v...), nil
+
+	case Ipv4Address, Ipv6Address:
+		ip, ok := ipValue(value)
+		if !ok {
+			return nil, ErrFieldType
+		}
+		if t == Ipv4Address {
+			ip = ip.To4()
+		} else {
+			ip = ip.To16()
+		}
+		if ip == nil {
+			return nil, ErrFieldValue
+		}
+		return append([]byte{}, ip...), nil
+
+	case DateTimeSeconds, DateTimeMilliseconds, DateTimeMicroseconds, DateTimeNanoseconds:
+		v, ok := value.(time.Time)
+		if !ok {
+			return nil, ErrFieldType
+		}
+		switch t {
+		case DateTimeSeconds:
+			s := v.Unix()
+			if s < 0 || s > math.MaxUint32 {
+				return nil, ErrFieldValue
+			}
+			return putNumber(uint64(s), 4), nil
+		case DateTimeMilliseconds:
+			// UnixNano overflows after 2262
+			ms := v.Unix()*1000 + int64(v.Nanosecond())/int64(time.Millisecond)
+			if ms < 0 {
+				return nil, ErrFieldValue
+			}
+			return putNumber(uint64(ms), 8), nil
+		case DateTimeMicroseconds:
+			if unixTimes {
+				us := v.Unix()*1000000 + int64(v.Nanosecond())/int64(time.Microsecond)
+				if us < 0 {
+					return nil, ErrFieldValue
+				}
+				return putNumber(uint64(us), 8), nil
+			}
+			return putNumber(ntpValue(v, time.Microsecond), 8), nil
+		default:
+			if unixTimes {
+				return putNumber(uint64(v.UnixNano()), 8), nil
+			}
+			return putNumber(ntpValue(v, time.Nanosecond), 8), nil
+		}
+
+	case String:
+		v, ok := value.(string)
+		if !ok {
+			return nil, ErrFieldType
+		}
+		if !variable && len(v) > int(length) {
+			return nil, ErrFieldValue
+		}
+		return padded([]byte(v), length), nil
+
+	case Unknown, OctetArray:
+		v, ok := value.(string)
+		if !ok {
+			return nil, ErrFieldType
+		}
+		if !variable && len(v) != int(length) {
+			return nil, ErrFieldValue
+		}
+		return []byte(v), nil
+	}
+	return nil, ErrFieldType
+}
+
+// padded copies bs into a field of the given length, filling the rest with
+// zeroes. Variable-length fields are not padded.
+func padded(bs []byte, length uint16) []byte {
+	if length == 0xffff {
+		return append([]byte{}, bs...)
+	}
+	field := make([]byte, length)
+	copy(field, bs)
+	return field
+}
+
+// putNumber encodes the low length bytes of v in network byte order.
+func putNumber(v uint64, length uint16) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return append([]byte{}, buf[8-length:]...)
+}
+
+func unsignedValue(value interface{}) (uint64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			return 0, false
+		}
+		return uint64(v.Int()), true
+	}
+	return 0, false
+}
+
+func signedValue(value interface{}) (int64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+func ipValue(value interface{}) (net.IP, bool) {
+	switch v := value.(type) {
+	case net.IP:
+		return v, true
+	case *net.IP:
+		return *v, true
+	case ipAddr:
+		return net.IP(v.AsSlice()), true
+	}
+	return nil, false
+}
@@ -0,0 +1,30 @@
+//go:build go1.18
+// +build go1.18
+
+package ipfix
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestRecordBuilderNetip(t *testing.T) {
+	i := NewInterpreter(NewSession())
+	b := i.NewRecordBuilder(256, []TemplateFieldSpecifier{{FieldID: 8, Length: 4}, {FieldID: 28, Length: 16}})
+	if err := b.Set("sourceIPv4Address", netip.MustParseAddr("192.0.2.1")); err != nil {
+		t.Fatal("Set failed", err)
+	}
+	if err := b.Set("destinationIPv6Address", netip.MustParseAddr("2001:db8::1")); err != nil {
+		t.Fatal("Set failed", err)
+	}
+	dr, err := b.Record()
+	if err != nil {
+		t.Fatal("Record failed", err)
+	}
+	if got, _ := netip.AddrFromSlice(dr.Fields[1]); got.String() != "2001:db8::1" {
+		t.Error("Incorrect destinationIPv6Address", got)
+	}
+	if len(dr.Fields[0]) != 4 || dr.Fields[0][3] != 1 {
+		t.Error("Incorrect sourceIPv4Address", dr.Fields[0])
+	}
+}
@@ -0,0 +1,127 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+var builderTemplate = TemplateRecord{
+	TemplateID: 300,
+	FieldSpecifiers: []TemplateFieldSpecifier{
+		{FieldID: 8, Length: 4},       // sourceIPv4Address
+		{FieldID: 28, Length: 16},     // destinationIPv6Address
+		{FieldID: 1, Length: 4},       // octetDeltaCount, reduced-size
+		{FieldID: 4, Length: 1},       // protocolIdentifier
+		{FieldID: 152, Length: 8},     // flowStartMilliseconds
+		{FieldID: 56, Length: 6},      // sourceMacAddress
+		{FieldID: 82, Length: 0xffff}, // interfaceName
+	},
+}
+
+func TestRecordBuilder(t *testing.T) {
+	i := NewInterpreter(NewSession())
+	b := i.NewRecordBuilder(builderTemplate.TemplateID, builderTemplate.FieldSpecifiers)
+	start := time.Unix(1500000000, 123000000)
+	mac, _ := net.ParseMAC("00:11:22:33:44:55")
+	for _, err := range []error{
+		b.Set("sourceIPv4Address", net.ParseIP("192.0.2.1")),
+		b.Set("destinationIPv6Address", net.ParseIP("2001:db8::1")),
+		b.Set("octetDeltaCount", 123456),
+		b.SetField(0, 4, uint8(17)),
+		b.Set("flowStartMilliseconds", start),
+		b.Set("sourceMacAddress", mac),
+		b.Set("interfaceName", "eth0"),
+	} {
+		if err != nil {
+			t.Fatal("Set failed", err)
+		}
+	}
+	dr, err := b.Record()
+	if err != nil {
+		t.Fatal("Record failed", err)
+	}
+
+	bs, err := Message{
+		Header:          MessageHeader{Version: 10},
+		TemplateRecords: []TemplateRecord{builderTemplate},
+		DataRecords:     []DataRecord{dr},
+	}.Marshal()
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	s := NewSession()
+	msg, err := s.ParseBuffer(bs)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(msg.DataRecords) != 1 {
+		t.Fatal("Incorrect number of data records", len(msg.DataRecords))
+	}
+	fields := NewInterpreter(s).Interpret(msg.DataRecords[0])
+	if v := fields[0].Value.(*net.IP); v.String() != "192.0.2.1" {
+		t.Error("Incorrect sourceIPv4Address", v)
+	}
+	if v := fields[1].Value.(*net.IP); v.String() != "2001:db8::1" {
+		t.Error("Incorrect destinationIPv6Address", v)
+	}
+	if v := fields[2].Value; v != uint64(123456) {
+		t.Error("Incorrect octetDeltaCount", v)
+	}
+	if v := fields[3].Value; v != uint8(17) {
+		t.Error("Incorrect protocolIdentifier", v)
+	}
+	if v := fields[4].Value.(time.Time); !v.Equal(start) {
+		t.Error("Incorrect flowStartMilliseconds", v)
+	}
+	if v := fields[5].Value.([]byte); net.HardwareAddr(v).String() != mac.String() {
+		t.Error("Incorrect sourceMacAddress", v)
+	}
+	if v := fields[6].Value; v != "eth0" {
+		t.Error("Incorrect interfaceName", v)
+	}
+}
+
+func TestRecordBuilderErrors(t *testing.T) {
+	i := NewInterpreter(NewSession())
+	b := i.NewRecordBuilder(builderTemplate.TemplateID, builderTemplate.FieldSpecifiers)
+	for _, c := range []struct {
+		name  string
+		value interface{}
+		err   error
+	}{
+		{"destinationIPv4Address", net.ParseIP("192.0.2.1"), ErrUnknownField},
+		{"sourceIPv4Address", "192.0.2.1", ErrFieldType},
+		{"sourceIPv4Address", net.ParseIP("2001:db8::1"), ErrFieldValue},
+		{"octetDeltaCount", uint64(1) << 32, ErrFieldValue},
+		{"octetDeltaCount", -1, ErrFieldValue},
+		{"protocolIdentifier", 1.5, ErrFieldType},
+		{"flowStartMilliseconds", time.Unix(-1, 0), ErrFieldValue},
+		{"sourceMacAddress", []byte{1, 2, 3}, ErrFieldValue},
+	} {
+		if err := b.Set(c.name, c.value); !errors.Is(err, c.err) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.err, err)
+		}
+	}
+	if _, err := b.Record(); !errors.Is(err, ErrFieldUnset) {
+		t.Error("Expected ErrFieldUnset, got", err)
+	}
+
+	// Reduced-size encoding never lengthens a field
+	b = i.NewRecordBuilder(256, []TemplateFieldSpecifier{{FieldID: 4, Length: 2}})
+	if err := b.SetIndex(0, 1); !errors.Is(err, ErrFieldValue) {
+		t.Error("Expected ErrFieldValue, got", err)
+	}
+
+	// Milliseconds past 2262 are beyond the range of UnixNano
+	late := time.Date(2300, 1, 1, 0, 0, 0, 5000000, time.UTC)
+	bs, err := encodeValue(late, DateTimeMilliseconds, 8, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := binary.BigEndian.Uint64(bs); v != uint64(late.Unix()*1000+5) {
+		t.Error("Incorrect milliseconds", v)
+	}
+}
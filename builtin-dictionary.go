@@ -68,72 +68,76 @@ var builtinNetflowV9Dictionary = fieldDictionary{
 	dictionaryKey{0, 79}: DictionaryEntry{FieldID: 79, Name: "MPLS_LABEL_10", Type: FieldTypes["unsigned24"]},
 }
 
-// Autogenerated Thu Mar 12 12:25:55 CET 2015
+// builtinIpfixDictionary holds the IANA information elements as of March
+// 2015. It was generated by etc/generate-builtin-dict.go, then edited by hand
+// to add the semantics, units, ranges and status of the elements in common
+// use. The Netflow v7 entries at the end are not from the registry and must
+// be kept when regenerating it.
 var builtinIpfixDictionary = fieldDictionary{
-	dictionaryKey{0, 1}:   DictionaryEntry{FieldID: 1, Name: "octetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 2}:   DictionaryEntry{FieldID: 2, Name: "packetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 3}:   DictionaryEntry{FieldID: 3, Name: "deltaFlowCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 4}:   DictionaryEntry{FieldID: 4, Name: "protocolIdentifier", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 5}:   DictionaryEntry{FieldID: 5, Name: "ipClassOfService", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 6}:   DictionaryEntry{FieldID: 6, Name: "tcpControlBits", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 7}:   DictionaryEntry{FieldID: 7, Name: "sourceTransportPort", Type: FieldTypes["unsigned16"]},
+	dictionaryKey{0, 1}:   DictionaryEntry{FieldID: 1, Name: "octetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 2}:   DictionaryEntry{FieldID: 2, Name: "packetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "packets"},
+	dictionaryKey{0, 3}:   DictionaryEntry{FieldID: 3, Name: "deltaFlowCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "flows"},
+	dictionaryKey{0, 4}:   DictionaryEntry{FieldID: 4, Name: "protocolIdentifier", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 5}:   DictionaryEntry{FieldID: 5, Name: "ipClassOfService", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 6}:   DictionaryEntry{FieldID: 6, Name: "tcpControlBits", Type: FieldTypes["unsigned16"], Semantics: Flags},
+	dictionaryKey{0, 7}:   DictionaryEntry{FieldID: 7, Name: "sourceTransportPort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
 	dictionaryKey{0, 8}:   DictionaryEntry{FieldID: 8, Name: "sourceIPv4Address", Type: FieldTypes["ipv4Address"]},
-	dictionaryKey{0, 9}:   DictionaryEntry{FieldID: 9, Name: "sourceIPv4PrefixLength", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 10}:  DictionaryEntry{FieldID: 10, Name: "ingressInterface", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 11}:  DictionaryEntry{FieldID: 11, Name: "destinationTransportPort", Type: FieldTypes["unsigned16"]},
+	dictionaryKey{0, 9}:   DictionaryEntry{FieldID: 9, Name: "sourceIPv4PrefixLength", Type: FieldTypes["unsigned8"], Units: "bits", Range: ValueRange{0, 32}},
+	dictionaryKey{0, 10}:  DictionaryEntry{FieldID: 10, Name: "ingressInterface", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 11}:  DictionaryEntry{FieldID: 11, Name: "destinationTransportPort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
 	dictionaryKey{0, 12}:  DictionaryEntry{FieldID: 12, Name: "destinationIPv4Address", Type: FieldTypes["ipv4Address"]},
-	dictionaryKey{0, 13}:  DictionaryEntry{FieldID: 13, Name: "destinationIPv4PrefixLength", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 14}:  DictionaryEntry{FieldID: 14, Name: "egressInterface", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 13}:  DictionaryEntry{FieldID: 13, Name: "destinationIPv4PrefixLength", Type: FieldTypes["unsigned8"], Units: "bits", Range: ValueRange{0, 32}},
+	dictionaryKey{0, 14}:  DictionaryEntry{FieldID: 14, Name: "egressInterface", Type: FieldTypes["unsigned32"], Semantics: Identifier},
 	dictionaryKey{0, 15}:  DictionaryEntry{FieldID: 15, Name: "ipNextHopIPv4Address", Type: FieldTypes["ipv4Address"]},
-	dictionaryKey{0, 16}:  DictionaryEntry{FieldID: 16, Name: "bgpSourceAsNumber", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 17}:  DictionaryEntry{FieldID: 17, Name: "bgpDestinationAsNumber", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 16}:  DictionaryEntry{FieldID: 16, Name: "bgpSourceAsNumber", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 17}:  DictionaryEntry{FieldID: 17, Name: "bgpDestinationAsNumber", Type: FieldTypes["unsigned32"], Semantics: Identifier},
 	dictionaryKey{0, 18}:  DictionaryEntry{FieldID: 18, Name: "bgpNextHopIPv4Address", Type: FieldTypes["ipv4Address"]},
-	dictionaryKey{0, 19}:  DictionaryEntry{FieldID: 19, Name: "postMCastPacketDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 20}:  DictionaryEntry{FieldID: 20, Name: "postMCastOctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 21}:  DictionaryEntry{FieldID: 21, Name: "flowEndSysUpTime", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 22}:  DictionaryEntry{FieldID: 22, Name: "flowStartSysUpTime", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 23}:  DictionaryEntry{FieldID: 23, Name: "postOctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 24}:  DictionaryEntry{FieldID: 24, Name: "postPacketDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 25}:  DictionaryEntry{FieldID: 25, Name: "minimumIpTotalLength", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 26}:  DictionaryEntry{FieldID: 26, Name: "maximumIpTotalLength", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 19}:  DictionaryEntry{FieldID: 19, Name: "postMCastPacketDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "packets"},
+	dictionaryKey{0, 20}:  DictionaryEntry{FieldID: 20, Name: "postMCastOctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 21}:  DictionaryEntry{FieldID: 21, Name: "flowEndSysUpTime", Type: FieldTypes["unsigned32"], Units: "milliseconds"},
+	dictionaryKey{0, 22}:  DictionaryEntry{FieldID: 22, Name: "flowStartSysUpTime", Type: FieldTypes["unsigned32"], Units: "milliseconds"},
+	dictionaryKey{0, 23}:  DictionaryEntry{FieldID: 23, Name: "postOctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 24}:  DictionaryEntry{FieldID: 24, Name: "postPacketDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "packets"},
+	dictionaryKey{0, 25}:  DictionaryEntry{FieldID: 25, Name: "minimumIpTotalLength", Type: FieldTypes["unsigned64"], Units: "octets"},
+	dictionaryKey{0, 26}:  DictionaryEntry{FieldID: 26, Name: "maximumIpTotalLength", Type: FieldTypes["unsigned64"], Units: "octets"},
 	dictionaryKey{0, 27}:  DictionaryEntry{FieldID: 27, Name: "sourceIPv6Address", Type: FieldTypes["ipv6Address"]},
 	dictionaryKey{0, 28}:  DictionaryEntry{FieldID: 28, Name: "destinationIPv6Address", Type: FieldTypes["ipv6Address"]},
-	dictionaryKey{0, 29}:  DictionaryEntry{FieldID: 29, Name: "sourceIPv6PrefixLength", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 30}:  DictionaryEntry{FieldID: 30, Name: "destinationIPv6PrefixLength", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 31}:  DictionaryEntry{FieldID: 31, Name: "flowLabelIPv6", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 32}:  DictionaryEntry{FieldID: 32, Name: "icmpTypeCodeIPv4", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 33}:  DictionaryEntry{FieldID: 33, Name: "igmpType", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 34}:  DictionaryEntry{FieldID: 34, Name: "samplingInterval", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 35}:  DictionaryEntry{FieldID: 35, Name: "samplingAlgorithm", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 36}:  DictionaryEntry{FieldID: 36, Name: "flowActiveTimeout", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 37}:  DictionaryEntry{FieldID: 37, Name: "flowIdleTimeout", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 38}:  DictionaryEntry{FieldID: 38, Name: "engineType", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 39}:  DictionaryEntry{FieldID: 39, Name: "engineId", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 40}:  DictionaryEntry{FieldID: 40, Name: "exportedOctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 41}:  DictionaryEntry{FieldID: 41, Name: "exportedMessageTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 42}:  DictionaryEntry{FieldID: 42, Name: "exportedFlowRecordTotalCount", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 29}:  DictionaryEntry{FieldID: 29, Name: "sourceIPv6PrefixLength", Type: FieldTypes["unsigned8"], Units: "bits", Range: ValueRange{0, 128}},
+	dictionaryKey{0, 30}:  DictionaryEntry{FieldID: 30, Name: "destinationIPv6PrefixLength", Type: FieldTypes["unsigned8"], Units: "bits", Range: ValueRange{0, 128}},
+	dictionaryKey{0, 31}:  DictionaryEntry{FieldID: 31, Name: "flowLabelIPv6", Type: FieldTypes["unsigned32"], Semantics: Identifier, Range: ValueRange{0, 1048575}},
+	dictionaryKey{0, 32}:  DictionaryEntry{FieldID: 32, Name: "icmpTypeCodeIPv4", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 33}:  DictionaryEntry{FieldID: 33, Name: "igmpType", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 34}:  DictionaryEntry{FieldID: 34, Name: "samplingInterval", Type: FieldTypes["unsigned32"], Status: "deprecated"},
+	dictionaryKey{0, 35}:  DictionaryEntry{FieldID: 35, Name: "samplingAlgorithm", Type: FieldTypes["unsigned8"], Status: "deprecated"},
+	dictionaryKey{0, 36}:  DictionaryEntry{FieldID: 36, Name: "flowActiveTimeout", Type: FieldTypes["unsigned16"], Units: "seconds"},
+	dictionaryKey{0, 37}:  DictionaryEntry{FieldID: 37, Name: "flowIdleTimeout", Type: FieldTypes["unsigned16"], Units: "seconds"},
+	dictionaryKey{0, 38}:  DictionaryEntry{FieldID: 38, Name: "engineType", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 39}:  DictionaryEntry{FieldID: 39, Name: "engineId", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 40}:  DictionaryEntry{FieldID: 40, Name: "exportedOctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 41}:  DictionaryEntry{FieldID: 41, Name: "exportedMessageTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "messages"},
+	dictionaryKey{0, 42}:  DictionaryEntry{FieldID: 42, Name: "exportedFlowRecordTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "flows"},
 	dictionaryKey{0, 43}:  DictionaryEntry{FieldID: 43, Name: "ipv4RouterSc", Type: FieldTypes["ipv4Address"]},
 	dictionaryKey{0, 44}:  DictionaryEntry{FieldID: 44, Name: "sourceIPv4Prefix", Type: FieldTypes["ipv4Address"]},
 	dictionaryKey{0, 45}:  DictionaryEntry{FieldID: 45, Name: "destinationIPv4Prefix", Type: FieldTypes["ipv4Address"]},
 	dictionaryKey{0, 46}:  DictionaryEntry{FieldID: 46, Name: "mplsTopLabelType", Type: FieldTypes["unsigned8"]},
 	dictionaryKey{0, 47}:  DictionaryEntry{FieldID: 47, Name: "mplsTopLabelIPv4Address", Type: FieldTypes["ipv4Address"]},
-	dictionaryKey{0, 48}:  DictionaryEntry{FieldID: 48, Name: "samplerId", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 49}:  DictionaryEntry{FieldID: 49, Name: "samplerMode", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 50}:  DictionaryEntry{FieldID: 50, Name: "samplerRandomInterval", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 48}:  DictionaryEntry{FieldID: 48, Name: "samplerId", Type: FieldTypes["unsigned8"], Semantics: Identifier, Status: "deprecated"},
+	dictionaryKey{0, 49}:  DictionaryEntry{FieldID: 49, Name: "samplerMode", Type: FieldTypes["unsigned8"], Status: "deprecated"},
+	dictionaryKey{0, 50}:  DictionaryEntry{FieldID: 50, Name: "samplerRandomInterval", Type: FieldTypes["unsigned32"], Status: "deprecated"},
 	dictionaryKey{0, 51}:  DictionaryEntry{FieldID: 51, Name: "classId", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 52}:  DictionaryEntry{FieldID: 52, Name: "minimumTTL", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 53}:  DictionaryEntry{FieldID: 53, Name: "maximumTTL", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 54}:  DictionaryEntry{FieldID: 54, Name: "fragmentIdentification", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 55}:  DictionaryEntry{FieldID: 55, Name: "postIpClassOfService", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{0, 52}:  DictionaryEntry{FieldID: 52, Name: "minimumTTL", Type: FieldTypes["unsigned8"], Units: "hops"},
+	dictionaryKey{0, 53}:  DictionaryEntry{FieldID: 53, Name: "maximumTTL", Type: FieldTypes["unsigned8"], Units: "hops"},
+	dictionaryKey{0, 54}:  DictionaryEntry{FieldID: 54, Name: "fragmentIdentification", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 55}:  DictionaryEntry{FieldID: 55, Name: "postIpClassOfService", Type: FieldTypes["unsigned8"], Semantics: Identifier},
 	dictionaryKey{0, 56}:  DictionaryEntry{FieldID: 56, Name: "sourceMacAddress", Type: FieldTypes["macAddress"]},
 	dictionaryKey{0, 57}:  DictionaryEntry{FieldID: 57, Name: "postDestinationMacAddress", Type: FieldTypes["macAddress"]},
-	dictionaryKey{0, 58}:  DictionaryEntry{FieldID: 58, Name: "vlanId", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 59}:  DictionaryEntry{FieldID: 59, Name: "postVlanId", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 60}:  DictionaryEntry{FieldID: 60, Name: "ipVersion", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 61}:  DictionaryEntry{FieldID: 61, Name: "flowDirection", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{0, 58}:  DictionaryEntry{FieldID: 58, Name: "vlanId", Type: FieldTypes["unsigned16"], Semantics: Identifier, Range: ValueRange{0, 4095}},
+	dictionaryKey{0, 59}:  DictionaryEntry{FieldID: 59, Name: "postVlanId", Type: FieldTypes["unsigned16"], Semantics: Identifier, Range: ValueRange{0, 4095}},
+	dictionaryKey{0, 60}:  DictionaryEntry{FieldID: 60, Name: "ipVersion", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 61}:  DictionaryEntry{FieldID: 61, Name: "flowDirection", Type: FieldTypes["unsigned8"], Semantics: Identifier},
 	dictionaryKey{0, 62}:  DictionaryEntry{FieldID: 62, Name: "ipNextHopIPv6Address", Type: FieldTypes["ipv6Address"]},
 	dictionaryKey{0, 63}:  DictionaryEntry{FieldID: 63, Name: "bgpNextHopIPv6Address", Type: FieldTypes["ipv6Address"]},
-	dictionaryKey{0, 64}:  DictionaryEntry{FieldID: 64, Name: "ipv6ExtensionHeaders", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 64}:  DictionaryEntry{FieldID: 64, Name: "ipv6ExtensionHeaders", Type: FieldTypes["unsigned32"], Semantics: Flags},
 	dictionaryKey{0, 70}:  DictionaryEntry{FieldID: 70, Name: "mplsTopLabelStackSection", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 71}:  DictionaryEntry{FieldID: 71, Name: "mplsLabelStackSection2", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 72}:  DictionaryEntry{FieldID: 72, Name: "mplsLabelStackSection3", Type: FieldTypes["octetArray"]},
@@ -148,184 +152,184 @@ var builtinIpfixDictionary = fieldDictionary{
 	dictionaryKey{0, 81}:  DictionaryEntry{FieldID: 81, Name: "postSourceMacAddress", Type: FieldTypes["macAddress"]},
 	dictionaryKey{0, 82}:  DictionaryEntry{FieldID: 82, Name: "interfaceName", Type: FieldTypes["string"]},
 	dictionaryKey{0, 83}:  DictionaryEntry{FieldID: 83, Name: "interfaceDescription", Type: FieldTypes["string"]},
-	dictionaryKey{0, 84}:  DictionaryEntry{FieldID: 84, Name: "samplerName", Type: FieldTypes["string"]},
-	dictionaryKey{0, 85}:  DictionaryEntry{FieldID: 85, Name: "octetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 86}:  DictionaryEntry{FieldID: 86, Name: "packetTotalCount", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 84}:  DictionaryEntry{FieldID: 84, Name: "samplerName", Type: FieldTypes["string"], Status: "deprecated"},
+	dictionaryKey{0, 85}:  DictionaryEntry{FieldID: 85, Name: "octetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 86}:  DictionaryEntry{FieldID: 86, Name: "packetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
 	dictionaryKey{0, 87}:  DictionaryEntry{FieldID: 87, Name: "flagsAndSamplerId", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 88}:  DictionaryEntry{FieldID: 88, Name: "fragmentOffset", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 89}:  DictionaryEntry{FieldID: 89, Name: "forwardingStatus", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 90}:  DictionaryEntry{FieldID: 90, Name: "mplsVpnRouteDistinguisher", Type: FieldTypes["octetArray"]},
-	dictionaryKey{0, 91}:  DictionaryEntry{FieldID: 91, Name: "mplsTopLabelPrefixLength", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{0, 91}:  DictionaryEntry{FieldID: 91, Name: "mplsTopLabelPrefixLength", Type: FieldTypes["unsigned8"], Units: "bits", Range: ValueRange{0, 32}},
 	dictionaryKey{0, 92}:  DictionaryEntry{FieldID: 92, Name: "srcTrafficIndex", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 93}:  DictionaryEntry{FieldID: 93, Name: "dstTrafficIndex", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 94}:  DictionaryEntry{FieldID: 94, Name: "applicationDescription", Type: FieldTypes["string"]},
 	dictionaryKey{0, 95}:  DictionaryEntry{FieldID: 95, Name: "applicationId", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 96}:  DictionaryEntry{FieldID: 96, Name: "applicationName", Type: FieldTypes["string"]},
-	dictionaryKey{0, 98}:  DictionaryEntry{FieldID: 98, Name: "postIpDiffServCodePoint", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{0, 98}:  DictionaryEntry{FieldID: 98, Name: "postIpDiffServCodePoint", Type: FieldTypes["unsigned8"], Range: ValueRange{0, 63}},
 	dictionaryKey{0, 99}:  DictionaryEntry{FieldID: 99, Name: "multicastReplicationFactor", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 100}: DictionaryEntry{FieldID: 100, Name: "className", Type: FieldTypes["string"]},
-	dictionaryKey{0, 101}: DictionaryEntry{FieldID: 101, Name: "classificationEngineId", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{0, 101}: DictionaryEntry{FieldID: 101, Name: "classificationEngineId", Type: FieldTypes["unsigned8"], Semantics: Identifier},
 	dictionaryKey{0, 102}: DictionaryEntry{FieldID: 102, Name: "layer2packetSectionOffset", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 103}: DictionaryEntry{FieldID: 103, Name: "layer2packetSectionSize", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 104}: DictionaryEntry{FieldID: 104, Name: "layer2packetSectionData", Type: FieldTypes["octetArray"]},
-	dictionaryKey{0, 128}: DictionaryEntry{FieldID: 128, Name: "bgpNextAdjacentAsNumber", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 129}: DictionaryEntry{FieldID: 129, Name: "bgpPrevAdjacentAsNumber", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 128}: DictionaryEntry{FieldID: 128, Name: "bgpNextAdjacentAsNumber", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 129}: DictionaryEntry{FieldID: 129, Name: "bgpPrevAdjacentAsNumber", Type: FieldTypes["unsigned32"], Semantics: Identifier},
 	dictionaryKey{0, 130}: DictionaryEntry{FieldID: 130, Name: "exporterIPv4Address", Type: FieldTypes["ipv4Address"]},
 	dictionaryKey{0, 131}: DictionaryEntry{FieldID: 131, Name: "exporterIPv6Address", Type: FieldTypes["ipv6Address"]},
-	dictionaryKey{0, 132}: DictionaryEntry{FieldID: 132, Name: "droppedOctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 133}: DictionaryEntry{FieldID: 133, Name: "droppedPacketDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 134}: DictionaryEntry{FieldID: 134, Name: "droppedOctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 135}: DictionaryEntry{FieldID: 135, Name: "droppedPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 136}: DictionaryEntry{FieldID: 136, Name: "flowEndReason", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 137}: DictionaryEntry{FieldID: 137, Name: "commonPropertiesId", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 138}: DictionaryEntry{FieldID: 138, Name: "observationPointId", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 139}: DictionaryEntry{FieldID: 139, Name: "icmpTypeCodeIPv6", Type: FieldTypes["unsigned16"]},
+	dictionaryKey{0, 132}: DictionaryEntry{FieldID: 132, Name: "droppedOctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 133}: DictionaryEntry{FieldID: 133, Name: "droppedPacketDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "packets"},
+	dictionaryKey{0, 134}: DictionaryEntry{FieldID: 134, Name: "droppedOctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 135}: DictionaryEntry{FieldID: 135, Name: "droppedPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 136}: DictionaryEntry{FieldID: 136, Name: "flowEndReason", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 137}: DictionaryEntry{FieldID: 137, Name: "commonPropertiesId", Type: FieldTypes["unsigned64"], Semantics: Identifier},
+	dictionaryKey{0, 138}: DictionaryEntry{FieldID: 138, Name: "observationPointId", Type: FieldTypes["unsigned64"], Semantics: Identifier},
+	dictionaryKey{0, 139}: DictionaryEntry{FieldID: 139, Name: "icmpTypeCodeIPv6", Type: FieldTypes["unsigned16"], Semantics: Identifier},
 	dictionaryKey{0, 140}: DictionaryEntry{FieldID: 140, Name: "mplsTopLabelIPv6Address", Type: FieldTypes["ipv6Address"]},
-	dictionaryKey{0, 141}: DictionaryEntry{FieldID: 141, Name: "lineCardId", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 142}: DictionaryEntry{FieldID: 142, Name: "portId", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 143}: DictionaryEntry{FieldID: 143, Name: "meteringProcessId", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 144}: DictionaryEntry{FieldID: 144, Name: "exportingProcessId", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 145}: DictionaryEntry{FieldID: 145, Name: "templateId", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 146}: DictionaryEntry{FieldID: 146, Name: "wlanChannelId", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 147}: DictionaryEntry{FieldID: 147, Name: "wlanSSID", Type: FieldTypes["string"]},
-	dictionaryKey{0, 148}: DictionaryEntry{FieldID: 148, Name: "flowId", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 149}: DictionaryEntry{FieldID: 149, Name: "observationDomainId", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 150}: DictionaryEntry{FieldID: 150, Name: "flowStartSeconds", Type: FieldTypes["dateTimeSeconds"]},
-	dictionaryKey{0, 151}: DictionaryEntry{FieldID: 151, Name: "flowEndSeconds", Type: FieldTypes["dateTimeSeconds"]},
-	dictionaryKey{0, 152}: DictionaryEntry{FieldID: 152, Name: "flowStartMilliseconds", Type: FieldTypes["dateTimeMilliseconds"]},
-	dictionaryKey{0, 153}: DictionaryEntry{FieldID: 153, Name: "flowEndMilliseconds", Type: FieldTypes["dateTimeMilliseconds"]},
-	dictionaryKey{0, 154}: DictionaryEntry{FieldID: 154, Name: "flowStartMicroseconds", Type: FieldTypes["dateTimeMicroseconds"]},
-	dictionaryKey{0, 155}: DictionaryEntry{FieldID: 155, Name: "flowEndMicroseconds", Type: FieldTypes["dateTimeMicroseconds"]},
-	dictionaryKey{0, 156}: DictionaryEntry{FieldID: 156, Name: "flowStartNanoseconds", Type: FieldTypes["dateTimeNanoseconds"]},
-	dictionaryKey{0, 157}: DictionaryEntry{FieldID: 157, Name: "flowEndNanoseconds", Type: FieldTypes["dateTimeNanoseconds"]},
-	dictionaryKey{0, 158}: DictionaryEntry{FieldID: 158, Name: "flowStartDeltaMicroseconds", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 159}: DictionaryEntry{FieldID: 159, Name: "flowEndDeltaMicroseconds", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 160}: DictionaryEntry{FieldID: 160, Name: "systemInitTimeMilliseconds", Type: FieldTypes["dateTimeMilliseconds"]},
-	dictionaryKey{0, 161}: DictionaryEntry{FieldID: 161, Name: "flowDurationMilliseconds", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 162}: DictionaryEntry{FieldID: 162, Name: "flowDurationMicroseconds", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 163}: DictionaryEntry{FieldID: 163, Name: "observedFlowTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 164}: DictionaryEntry{FieldID: 164, Name: "ignoredPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 165}: DictionaryEntry{FieldID: 165, Name: "ignoredOctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 166}: DictionaryEntry{FieldID: 166, Name: "notSentFlowTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 167}: DictionaryEntry{FieldID: 167, Name: "notSentPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 168}: DictionaryEntry{FieldID: 168, Name: "notSentOctetTotalCount", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 141}: DictionaryEntry{FieldID: 141, Name: "lineCardId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 142}: DictionaryEntry{FieldID: 142, Name: "portId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 143}: DictionaryEntry{FieldID: 143, Name: "meteringProcessId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 144}: DictionaryEntry{FieldID: 144, Name: "exportingProcessId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 145}: DictionaryEntry{FieldID: 145, Name: "templateId", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 146}: DictionaryEntry{FieldID: 146, Name: "wlanChannelId", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 147}: DictionaryEntry{FieldID: 147, Name: "wlanSSID", Type: FieldTypes["string"], Semantics: Identifier},
+	dictionaryKey{0, 148}: DictionaryEntry{FieldID: 148, Name: "flowId", Type: FieldTypes["unsigned64"], Semantics: Identifier},
+	dictionaryKey{0, 149}: DictionaryEntry{FieldID: 149, Name: "observationDomainId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 150}: DictionaryEntry{FieldID: 150, Name: "flowStartSeconds", Type: FieldTypes["dateTimeSeconds"], Units: "seconds"},
+	dictionaryKey{0, 151}: DictionaryEntry{FieldID: 151, Name: "flowEndSeconds", Type: FieldTypes["dateTimeSeconds"], Units: "seconds"},
+	dictionaryKey{0, 152}: DictionaryEntry{FieldID: 152, Name: "flowStartMilliseconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
+	dictionaryKey{0, 153}: DictionaryEntry{FieldID: 153, Name: "flowEndMilliseconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
+	dictionaryKey{0, 154}: DictionaryEntry{FieldID: 154, Name: "flowStartMicroseconds", Type: FieldTypes["dateTimeMicroseconds"], Units: "microseconds"},
+	dictionaryKey{0, 155}: DictionaryEntry{FieldID: 155, Name: "flowEndMicroseconds", Type: FieldTypes["dateTimeMicroseconds"], Units: "microseconds"},
+	dictionaryKey{0, 156}: DictionaryEntry{FieldID: 156, Name: "flowStartNanoseconds", Type: FieldTypes["dateTimeNanoseconds"], Units: "nanoseconds"},
+	dictionaryKey{0, 157}: DictionaryEntry{FieldID: 157, Name: "flowEndNanoseconds", Type: FieldTypes["dateTimeNanoseconds"], Units: "nanoseconds"},
+	dictionaryKey{0, 158}: DictionaryEntry{FieldID: 158, Name: "flowStartDeltaMicroseconds", Type: FieldTypes["unsigned32"], Units: "microseconds"},
+	dictionaryKey{0, 159}: DictionaryEntry{FieldID: 159, Name: "flowEndDeltaMicroseconds", Type: FieldTypes["unsigned32"], Units: "microseconds"},
+	dictionaryKey{0, 160}: DictionaryEntry{FieldID: 160, Name: "systemInitTimeMilliseconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
+	dictionaryKey{0, 161}: DictionaryEntry{FieldID: 161, Name: "flowDurationMilliseconds", Type: FieldTypes["unsigned32"], Units: "milliseconds"},
+	dictionaryKey{0, 162}: DictionaryEntry{FieldID: 162, Name: "flowDurationMicroseconds", Type: FieldTypes["unsigned32"], Units: "microseconds"},
+	dictionaryKey{0, 163}: DictionaryEntry{FieldID: 163, Name: "observedFlowTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "flows"},
+	dictionaryKey{0, 164}: DictionaryEntry{FieldID: 164, Name: "ignoredPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 165}: DictionaryEntry{FieldID: 165, Name: "ignoredOctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 166}: DictionaryEntry{FieldID: 166, Name: "notSentFlowTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "flows"},
+	dictionaryKey{0, 167}: DictionaryEntry{FieldID: 167, Name: "notSentPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 168}: DictionaryEntry{FieldID: 168, Name: "notSentOctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
 	dictionaryKey{0, 169}: DictionaryEntry{FieldID: 169, Name: "destinationIPv6Prefix", Type: FieldTypes["ipv6Address"]},
 	dictionaryKey{0, 170}: DictionaryEntry{FieldID: 170, Name: "sourceIPv6Prefix", Type: FieldTypes["ipv6Address"]},
-	dictionaryKey{0, 171}: DictionaryEntry{FieldID: 171, Name: "postOctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 172}: DictionaryEntry{FieldID: 172, Name: "postPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 173}: DictionaryEntry{FieldID: 173, Name: "flowKeyIndicator", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 174}: DictionaryEntry{FieldID: 174, Name: "postMCastPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 175}: DictionaryEntry{FieldID: 175, Name: "postMCastOctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 176}: DictionaryEntry{FieldID: 176, Name: "icmpTypeIPv4", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 177}: DictionaryEntry{FieldID: 177, Name: "icmpCodeIPv4", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 178}: DictionaryEntry{FieldID: 178, Name: "icmpTypeIPv6", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 179}: DictionaryEntry{FieldID: 179, Name: "icmpCodeIPv6", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 180}: DictionaryEntry{FieldID: 180, Name: "udpSourcePort", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 181}: DictionaryEntry{FieldID: 181, Name: "udpDestinationPort", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 182}: DictionaryEntry{FieldID: 182, Name: "tcpSourcePort", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 183}: DictionaryEntry{FieldID: 183, Name: "tcpDestinationPort", Type: FieldTypes["unsigned16"]},
+	dictionaryKey{0, 171}: DictionaryEntry{FieldID: 171, Name: "postOctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 172}: DictionaryEntry{FieldID: 172, Name: "postPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 173}: DictionaryEntry{FieldID: 173, Name: "flowKeyIndicator", Type: FieldTypes["unsigned64"], Semantics: Flags},
+	dictionaryKey{0, 174}: DictionaryEntry{FieldID: 174, Name: "postMCastPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 175}: DictionaryEntry{FieldID: 175, Name: "postMCastOctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 176}: DictionaryEntry{FieldID: 176, Name: "icmpTypeIPv4", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 177}: DictionaryEntry{FieldID: 177, Name: "icmpCodeIPv4", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 178}: DictionaryEntry{FieldID: 178, Name: "icmpTypeIPv6", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 179}: DictionaryEntry{FieldID: 179, Name: "icmpCodeIPv6", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 180}: DictionaryEntry{FieldID: 180, Name: "udpSourcePort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 181}: DictionaryEntry{FieldID: 181, Name: "udpDestinationPort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 182}: DictionaryEntry{FieldID: 182, Name: "tcpSourcePort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 183}: DictionaryEntry{FieldID: 183, Name: "tcpDestinationPort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
 	dictionaryKey{0, 184}: DictionaryEntry{FieldID: 184, Name: "tcpSequenceNumber", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 185}: DictionaryEntry{FieldID: 185, Name: "tcpAcknowledgementNumber", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 186}: DictionaryEntry{FieldID: 186, Name: "tcpWindowSize", Type: FieldTypes["unsigned16"]},
+	dictionaryKey{0, 186}: DictionaryEntry{FieldID: 186, Name: "tcpWindowSize", Type: FieldTypes["unsigned16"], Units: "octets"},
 	dictionaryKey{0, 187}: DictionaryEntry{FieldID: 187, Name: "tcpUrgentPointer", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 188}: DictionaryEntry{FieldID: 188, Name: "tcpHeaderLength", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 189}: DictionaryEntry{FieldID: 189, Name: "ipHeaderLength", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 190}: DictionaryEntry{FieldID: 190, Name: "totalLengthIPv4", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 191}: DictionaryEntry{FieldID: 191, Name: "payloadLengthIPv6", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 192}: DictionaryEntry{FieldID: 192, Name: "ipTTL", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 193}: DictionaryEntry{FieldID: 193, Name: "nextHeaderIPv6", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 194}: DictionaryEntry{FieldID: 194, Name: "mplsPayloadLength", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 195}: DictionaryEntry{FieldID: 195, Name: "ipDiffServCodePoint", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 196}: DictionaryEntry{FieldID: 196, Name: "ipPrecedence", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 197}: DictionaryEntry{FieldID: 197, Name: "fragmentFlags", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{0, 188}: DictionaryEntry{FieldID: 188, Name: "tcpHeaderLength", Type: FieldTypes["unsigned8"], Units: "octets"},
+	dictionaryKey{0, 189}: DictionaryEntry{FieldID: 189, Name: "ipHeaderLength", Type: FieldTypes["unsigned8"], Units: "octets"},
+	dictionaryKey{0, 190}: DictionaryEntry{FieldID: 190, Name: "totalLengthIPv4", Type: FieldTypes["unsigned16"], Units: "octets"},
+	dictionaryKey{0, 191}: DictionaryEntry{FieldID: 191, Name: "payloadLengthIPv6", Type: FieldTypes["unsigned16"], Units: "octets"},
+	dictionaryKey{0, 192}: DictionaryEntry{FieldID: 192, Name: "ipTTL", Type: FieldTypes["unsigned8"], Units: "hops"},
+	dictionaryKey{0, 193}: DictionaryEntry{FieldID: 193, Name: "nextHeaderIPv6", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 194}: DictionaryEntry{FieldID: 194, Name: "mplsPayloadLength", Type: FieldTypes["unsigned32"], Units: "octets"},
+	dictionaryKey{0, 195}: DictionaryEntry{FieldID: 195, Name: "ipDiffServCodePoint", Type: FieldTypes["unsigned8"], Semantics: Identifier, Range: ValueRange{0, 63}},
+	dictionaryKey{0, 196}: DictionaryEntry{FieldID: 196, Name: "ipPrecedence", Type: FieldTypes["unsigned8"], Semantics: Identifier, Range: ValueRange{0, 7}},
+	dictionaryKey{0, 197}: DictionaryEntry{FieldID: 197, Name: "fragmentFlags", Type: FieldTypes["unsigned8"], Semantics: Flags},
 	dictionaryKey{0, 198}: DictionaryEntry{FieldID: 198, Name: "octetDeltaSumOfSquares", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 199}: DictionaryEntry{FieldID: 199, Name: "octetTotalSumOfSquares", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 200}: DictionaryEntry{FieldID: 200, Name: "mplsTopLabelTTL", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 201}: DictionaryEntry{FieldID: 201, Name: "mplsLabelStackLength", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 200}: DictionaryEntry{FieldID: 200, Name: "mplsTopLabelTTL", Type: FieldTypes["unsigned8"], Units: "hops"},
+	dictionaryKey{0, 201}: DictionaryEntry{FieldID: 201, Name: "mplsLabelStackLength", Type: FieldTypes["unsigned32"], Units: "octets"},
 	dictionaryKey{0, 202}: DictionaryEntry{FieldID: 202, Name: "mplsLabelStackDepth", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 203}: DictionaryEntry{FieldID: 203, Name: "mplsTopLabelExp", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 204}: DictionaryEntry{FieldID: 204, Name: "ipPayloadLength", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 205}: DictionaryEntry{FieldID: 205, Name: "udpMessageLength", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 206}: DictionaryEntry{FieldID: 206, Name: "isMulticast", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 207}: DictionaryEntry{FieldID: 207, Name: "ipv4IHL", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 208}: DictionaryEntry{FieldID: 208, Name: "ipv4Options", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 209}: DictionaryEntry{FieldID: 209, Name: "tcpOptions", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 203}: DictionaryEntry{FieldID: 203, Name: "mplsTopLabelExp", Type: FieldTypes["unsigned8"], Range: ValueRange{0, 7}},
+	dictionaryKey{0, 204}: DictionaryEntry{FieldID: 204, Name: "ipPayloadLength", Type: FieldTypes["unsigned32"], Units: "octets"},
+	dictionaryKey{0, 205}: DictionaryEntry{FieldID: 205, Name: "udpMessageLength", Type: FieldTypes["unsigned16"], Units: "octets"},
+	dictionaryKey{0, 206}: DictionaryEntry{FieldID: 206, Name: "isMulticast", Type: FieldTypes["unsigned8"], Semantics: Flags},
+	dictionaryKey{0, 207}: DictionaryEntry{FieldID: 207, Name: "ipv4IHL", Type: FieldTypes["unsigned8"], Units: "4-octet words"},
+	dictionaryKey{0, 208}: DictionaryEntry{FieldID: 208, Name: "ipv4Options", Type: FieldTypes["unsigned32"], Semantics: Flags},
+	dictionaryKey{0, 209}: DictionaryEntry{FieldID: 209, Name: "tcpOptions", Type: FieldTypes["unsigned64"], Semantics: Flags},
 	dictionaryKey{0, 210}: DictionaryEntry{FieldID: 210, Name: "paddingOctets", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 211}: DictionaryEntry{FieldID: 211, Name: "collectorIPv4Address", Type: FieldTypes["ipv4Address"]},
 	dictionaryKey{0, 212}: DictionaryEntry{FieldID: 212, Name: "collectorIPv6Address", Type: FieldTypes["ipv6Address"]},
-	dictionaryKey{0, 213}: DictionaryEntry{FieldID: 213, Name: "exportInterface", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 214}: DictionaryEntry{FieldID: 214, Name: "exportProtocolVersion", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 215}: DictionaryEntry{FieldID: 215, Name: "exportTransportProtocol", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 216}: DictionaryEntry{FieldID: 216, Name: "collectorTransportPort", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 217}: DictionaryEntry{FieldID: 217, Name: "exporterTransportPort", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 218}: DictionaryEntry{FieldID: 218, Name: "tcpSynTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 219}: DictionaryEntry{FieldID: 219, Name: "tcpFinTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 220}: DictionaryEntry{FieldID: 220, Name: "tcpRstTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 221}: DictionaryEntry{FieldID: 221, Name: "tcpPshTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 222}: DictionaryEntry{FieldID: 222, Name: "tcpAckTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 223}: DictionaryEntry{FieldID: 223, Name: "tcpUrgTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 224}: DictionaryEntry{FieldID: 224, Name: "ipTotalLength", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 213}: DictionaryEntry{FieldID: 213, Name: "exportInterface", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 214}: DictionaryEntry{FieldID: 214, Name: "exportProtocolVersion", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 215}: DictionaryEntry{FieldID: 215, Name: "exportTransportProtocol", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 216}: DictionaryEntry{FieldID: 216, Name: "collectorTransportPort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 217}: DictionaryEntry{FieldID: 217, Name: "exporterTransportPort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 218}: DictionaryEntry{FieldID: 218, Name: "tcpSynTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
+	dictionaryKey{0, 219}: DictionaryEntry{FieldID: 219, Name: "tcpFinTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
+	dictionaryKey{0, 220}: DictionaryEntry{FieldID: 220, Name: "tcpRstTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
+	dictionaryKey{0, 221}: DictionaryEntry{FieldID: 221, Name: "tcpPshTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
+	dictionaryKey{0, 222}: DictionaryEntry{FieldID: 222, Name: "tcpAckTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
+	dictionaryKey{0, 223}: DictionaryEntry{FieldID: 223, Name: "tcpUrgTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
+	dictionaryKey{0, 224}: DictionaryEntry{FieldID: 224, Name: "ipTotalLength", Type: FieldTypes["unsigned64"], Units: "octets"},
 	dictionaryKey{0, 225}: DictionaryEntry{FieldID: 225, Name: "postNATSourceIPv4Address", Type: FieldTypes["ipv4Address"]},
 	dictionaryKey{0, 226}: DictionaryEntry{FieldID: 226, Name: "postNATDestinationIPv4Address", Type: FieldTypes["ipv4Address"]},
-	dictionaryKey{0, 227}: DictionaryEntry{FieldID: 227, Name: "postNAPTSourceTransportPort", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 228}: DictionaryEntry{FieldID: 228, Name: "postNAPTDestinationTransportPort", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 229}: DictionaryEntry{FieldID: 229, Name: "natOriginatingAddressRealm", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 230}: DictionaryEntry{FieldID: 230, Name: "natEvent", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 231}: DictionaryEntry{FieldID: 231, Name: "initiatorOctets", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 232}: DictionaryEntry{FieldID: 232, Name: "responderOctets", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 233}: DictionaryEntry{FieldID: 233, Name: "firewallEvent", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 234}: DictionaryEntry{FieldID: 234, Name: "ingressVRFID", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 235}: DictionaryEntry{FieldID: 235, Name: "egressVRFID", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 227}: DictionaryEntry{FieldID: 227, Name: "postNAPTSourceTransportPort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 228}: DictionaryEntry{FieldID: 228, Name: "postNAPTDestinationTransportPort", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 229}: DictionaryEntry{FieldID: 229, Name: "natOriginatingAddressRealm", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 230}: DictionaryEntry{FieldID: 230, Name: "natEvent", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 231}: DictionaryEntry{FieldID: 231, Name: "initiatorOctets", Type: FieldTypes["unsigned64"], Units: "octets"},
+	dictionaryKey{0, 232}: DictionaryEntry{FieldID: 232, Name: "responderOctets", Type: FieldTypes["unsigned64"], Units: "octets"},
+	dictionaryKey{0, 233}: DictionaryEntry{FieldID: 233, Name: "firewallEvent", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 234}: DictionaryEntry{FieldID: 234, Name: "ingressVRFID", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 235}: DictionaryEntry{FieldID: 235, Name: "egressVRFID", Type: FieldTypes["unsigned32"], Semantics: Identifier},
 	dictionaryKey{0, 236}: DictionaryEntry{FieldID: 236, Name: "VRFname", Type: FieldTypes["string"]},
-	dictionaryKey{0, 237}: DictionaryEntry{FieldID: 237, Name: "postMplsTopLabelExp", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{0, 237}: DictionaryEntry{FieldID: 237, Name: "postMplsTopLabelExp", Type: FieldTypes["unsigned8"], Range: ValueRange{0, 7}},
 	dictionaryKey{0, 238}: DictionaryEntry{FieldID: 238, Name: "tcpWindowScale", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 239}: DictionaryEntry{FieldID: 239, Name: "biflowDirection", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 240}: DictionaryEntry{FieldID: 240, Name: "ethernetHeaderLength", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 241}: DictionaryEntry{FieldID: 241, Name: "ethernetPayloadLength", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 242}: DictionaryEntry{FieldID: 242, Name: "ethernetTotalLength", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 243}: DictionaryEntry{FieldID: 243, Name: "dot1qVlanId", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 244}: DictionaryEntry{FieldID: 244, Name: "dot1qPriority", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 245}: DictionaryEntry{FieldID: 245, Name: "dot1qCustomerVlanId", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 246}: DictionaryEntry{FieldID: 246, Name: "dot1qCustomerPriority", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{0, 239}: DictionaryEntry{FieldID: 239, Name: "biflowDirection", Type: FieldTypes["unsigned8"], Semantics: Identifier},
+	dictionaryKey{0, 240}: DictionaryEntry{FieldID: 240, Name: "ethernetHeaderLength", Type: FieldTypes["unsigned8"], Units: "octets"},
+	dictionaryKey{0, 241}: DictionaryEntry{FieldID: 241, Name: "ethernetPayloadLength", Type: FieldTypes["unsigned16"], Units: "octets"},
+	dictionaryKey{0, 242}: DictionaryEntry{FieldID: 242, Name: "ethernetTotalLength", Type: FieldTypes["unsigned16"], Units: "octets"},
+	dictionaryKey{0, 243}: DictionaryEntry{FieldID: 243, Name: "dot1qVlanId", Type: FieldTypes["unsigned16"], Semantics: Identifier, Range: ValueRange{0, 4095}},
+	dictionaryKey{0, 244}: DictionaryEntry{FieldID: 244, Name: "dot1qPriority", Type: FieldTypes["unsigned8"], Range: ValueRange{0, 7}},
+	dictionaryKey{0, 245}: DictionaryEntry{FieldID: 245, Name: "dot1qCustomerVlanId", Type: FieldTypes["unsigned16"], Semantics: Identifier, Range: ValueRange{0, 4095}},
+	dictionaryKey{0, 246}: DictionaryEntry{FieldID: 246, Name: "dot1qCustomerPriority", Type: FieldTypes["unsigned8"], Range: ValueRange{0, 7}},
 	dictionaryKey{0, 247}: DictionaryEntry{FieldID: 247, Name: "metroEvcId", Type: FieldTypes["string"]},
 	dictionaryKey{0, 248}: DictionaryEntry{FieldID: 248, Name: "metroEvcType", Type: FieldTypes["unsigned8"]},
 	dictionaryKey{0, 249}: DictionaryEntry{FieldID: 249, Name: "pseudoWireId", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 250}: DictionaryEntry{FieldID: 250, Name: "pseudoWireType", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 251}: DictionaryEntry{FieldID: 251, Name: "pseudoWireControlWord", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 252}: DictionaryEntry{FieldID: 252, Name: "ingressPhysicalInterface", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 253}: DictionaryEntry{FieldID: 253, Name: "egressPhysicalInterface", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 254}: DictionaryEntry{FieldID: 254, Name: "postDot1qVlanId", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 255}: DictionaryEntry{FieldID: 255, Name: "postDot1qCustomerVlanId", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 256}: DictionaryEntry{FieldID: 256, Name: "ethernetType", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 257}: DictionaryEntry{FieldID: 257, Name: "postIpPrecedence", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 258}: DictionaryEntry{FieldID: 258, Name: "collectionTimeMilliseconds", Type: FieldTypes["dateTimeMilliseconds"]},
+	dictionaryKey{0, 252}: DictionaryEntry{FieldID: 252, Name: "ingressPhysicalInterface", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 253}: DictionaryEntry{FieldID: 253, Name: "egressPhysicalInterface", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 254}: DictionaryEntry{FieldID: 254, Name: "postDot1qVlanId", Type: FieldTypes["unsigned16"], Semantics: Identifier, Range: ValueRange{0, 4095}},
+	dictionaryKey{0, 255}: DictionaryEntry{FieldID: 255, Name: "postDot1qCustomerVlanId", Type: FieldTypes["unsigned16"], Semantics: Identifier, Range: ValueRange{0, 4095}},
+	dictionaryKey{0, 256}: DictionaryEntry{FieldID: 256, Name: "ethernetType", Type: FieldTypes["unsigned16"], Semantics: Identifier},
+	dictionaryKey{0, 257}: DictionaryEntry{FieldID: 257, Name: "postIpPrecedence", Type: FieldTypes["unsigned8"], Range: ValueRange{0, 7}},
+	dictionaryKey{0, 258}: DictionaryEntry{FieldID: 258, Name: "collectionTimeMilliseconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
 	dictionaryKey{0, 259}: DictionaryEntry{FieldID: 259, Name: "exportSctpStreamId", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 260}: DictionaryEntry{FieldID: 260, Name: "maxExportSeconds", Type: FieldTypes["dateTimeSeconds"]},
-	dictionaryKey{0, 261}: DictionaryEntry{FieldID: 261, Name: "maxFlowEndSeconds", Type: FieldTypes["dateTimeSeconds"]},
+	dictionaryKey{0, 260}: DictionaryEntry{FieldID: 260, Name: "maxExportSeconds", Type: FieldTypes["dateTimeSeconds"], Units: "seconds"},
+	dictionaryKey{0, 261}: DictionaryEntry{FieldID: 261, Name: "maxFlowEndSeconds", Type: FieldTypes["dateTimeSeconds"], Units: "seconds"},
 	dictionaryKey{0, 262}: DictionaryEntry{FieldID: 262, Name: "messageMD5Checksum", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 263}: DictionaryEntry{FieldID: 263, Name: "messageScope", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 264}: DictionaryEntry{FieldID: 264, Name: "minExportSeconds", Type: FieldTypes["dateTimeSeconds"]},
-	dictionaryKey{0, 265}: DictionaryEntry{FieldID: 265, Name: "minFlowStartSeconds", Type: FieldTypes["dateTimeSeconds"]},
+	dictionaryKey{0, 264}: DictionaryEntry{FieldID: 264, Name: "minExportSeconds", Type: FieldTypes["dateTimeSeconds"], Units: "seconds"},
+	dictionaryKey{0, 265}: DictionaryEntry{FieldID: 265, Name: "minFlowStartSeconds", Type: FieldTypes["dateTimeSeconds"], Units: "seconds"},
 	dictionaryKey{0, 266}: DictionaryEntry{FieldID: 266, Name: "opaqueOctets", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 267}: DictionaryEntry{FieldID: 267, Name: "sessionScope", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 268}: DictionaryEntry{FieldID: 268, Name: "maxFlowEndMicroseconds", Type: FieldTypes["dateTimeMicroseconds"]},
-	dictionaryKey{0, 269}: DictionaryEntry{FieldID: 269, Name: "maxFlowEndMilliseconds", Type: FieldTypes["dateTimeMilliseconds"]},
-	dictionaryKey{0, 270}: DictionaryEntry{FieldID: 270, Name: "maxFlowEndNanoseconds", Type: FieldTypes["dateTimeNanoseconds"]},
-	dictionaryKey{0, 271}: DictionaryEntry{FieldID: 271, Name: "minFlowStartMicroseconds", Type: FieldTypes["dateTimeMicroseconds"]},
-	dictionaryKey{0, 272}: DictionaryEntry{FieldID: 272, Name: "minFlowStartMilliseconds", Type: FieldTypes["dateTimeMilliseconds"]},
-	dictionaryKey{0, 273}: DictionaryEntry{FieldID: 273, Name: "minFlowStartNanoseconds", Type: FieldTypes["dateTimeNanoseconds"]},
+	dictionaryKey{0, 268}: DictionaryEntry{FieldID: 268, Name: "maxFlowEndMicroseconds", Type: FieldTypes["dateTimeMicroseconds"], Units: "microseconds"},
+	dictionaryKey{0, 269}: DictionaryEntry{FieldID: 269, Name: "maxFlowEndMilliseconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
+	dictionaryKey{0, 270}: DictionaryEntry{FieldID: 270, Name: "maxFlowEndNanoseconds", Type: FieldTypes["dateTimeNanoseconds"], Units: "nanoseconds"},
+	dictionaryKey{0, 271}: DictionaryEntry{FieldID: 271, Name: "minFlowStartMicroseconds", Type: FieldTypes["dateTimeMicroseconds"], Units: "microseconds"},
+	dictionaryKey{0, 272}: DictionaryEntry{FieldID: 272, Name: "minFlowStartMilliseconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
+	dictionaryKey{0, 273}: DictionaryEntry{FieldID: 273, Name: "minFlowStartNanoseconds", Type: FieldTypes["dateTimeNanoseconds"], Units: "nanoseconds"},
 	dictionaryKey{0, 274}: DictionaryEntry{FieldID: 274, Name: "collectorCertificate", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 275}: DictionaryEntry{FieldID: 275, Name: "exporterCertificate", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 276}: DictionaryEntry{FieldID: 276, Name: "dataRecordsReliability", Type: FieldTypes["boolean"]},
 	dictionaryKey{0, 277}: DictionaryEntry{FieldID: 277, Name: "observationPointType", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 278}: DictionaryEntry{FieldID: 278, Name: "newConnectionDeltaCount", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 279}: DictionaryEntry{FieldID: 279, Name: "connectionSumDurationSeconds", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 278}: DictionaryEntry{FieldID: 278, Name: "newConnectionDeltaCount", Type: FieldTypes["unsigned32"], Semantics: DeltaCounter},
+	dictionaryKey{0, 279}: DictionaryEntry{FieldID: 279, Name: "connectionSumDurationSeconds", Type: FieldTypes["unsigned64"], Units: "seconds"},
 	dictionaryKey{0, 280}: DictionaryEntry{FieldID: 280, Name: "connectionTransactionId", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 281}: DictionaryEntry{FieldID: 281, Name: "postNATSourceIPv6Address", Type: FieldTypes["ipv6Address"]},
 	dictionaryKey{0, 282}: DictionaryEntry{FieldID: 282, Name: "postNATDestinationIPv6Address", Type: FieldTypes["ipv6Address"]},
-	dictionaryKey{0, 283}: DictionaryEntry{FieldID: 283, Name: "natPoolId", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 283}: DictionaryEntry{FieldID: 283, Name: "natPoolId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
 	dictionaryKey{0, 284}: DictionaryEntry{FieldID: 284, Name: "natPoolName", Type: FieldTypes["string"]},
-	dictionaryKey{0, 285}: DictionaryEntry{FieldID: 285, Name: "anonymizationFlags", Type: FieldTypes["unsigned16"]},
+	dictionaryKey{0, 285}: DictionaryEntry{FieldID: 285, Name: "anonymizationFlags", Type: FieldTypes["unsigned16"], Semantics: Flags},
 	dictionaryKey{0, 286}: DictionaryEntry{FieldID: 286, Name: "anonymizationTechnique", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 287}: DictionaryEntry{FieldID: 287, Name: "informationElementIndex", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 288}: DictionaryEntry{FieldID: 288, Name: "p2pTechnology", Type: FieldTypes["string"]},
@@ -338,11 +342,11 @@ var builtinIpfixDictionary = fieldDictionary{
 	dictionaryKey{0, 295}: DictionaryEntry{FieldID: 295, Name: "IPSecSPI", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 296}: DictionaryEntry{FieldID: 296, Name: "greKey", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 297}: DictionaryEntry{FieldID: 297, Name: "natType", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 298}: DictionaryEntry{FieldID: 298, Name: "initiatorPackets", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 299}: DictionaryEntry{FieldID: 299, Name: "responderPackets", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 298}: DictionaryEntry{FieldID: 298, Name: "initiatorPackets", Type: FieldTypes["unsigned64"], Units: "packets"},
+	dictionaryKey{0, 299}: DictionaryEntry{FieldID: 299, Name: "responderPackets", Type: FieldTypes["unsigned64"], Units: "packets"},
 	dictionaryKey{0, 300}: DictionaryEntry{FieldID: 300, Name: "observationDomainName", Type: FieldTypes["string"]},
-	dictionaryKey{0, 301}: DictionaryEntry{FieldID: 301, Name: "selectionSequenceId", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 302}: DictionaryEntry{FieldID: 302, Name: "selectorId", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 301}: DictionaryEntry{FieldID: 301, Name: "selectionSequenceId", Type: FieldTypes["unsigned64"], Semantics: Identifier},
+	dictionaryKey{0, 302}: DictionaryEntry{FieldID: 302, Name: "selectorId", Type: FieldTypes["unsigned64"], Semantics: Identifier},
 	dictionaryKey{0, 303}: DictionaryEntry{FieldID: 303, Name: "informationElementId", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 304}: DictionaryEntry{FieldID: 304, Name: "selectorAlgorithm", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 305}: DictionaryEntry{FieldID: 305, Name: "samplingPacketInterval", Type: FieldTypes["unsigned32"]},
@@ -352,20 +356,20 @@ var builtinIpfixDictionary = fieldDictionary{
 	dictionaryKey{0, 309}: DictionaryEntry{FieldID: 309, Name: "samplingSize", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 310}: DictionaryEntry{FieldID: 310, Name: "samplingPopulation", Type: FieldTypes["unsigned32"]},
 	dictionaryKey{0, 311}: DictionaryEntry{FieldID: 311, Name: "samplingProbability", Type: FieldTypes["float64"]},
-	dictionaryKey{0, 312}: DictionaryEntry{FieldID: 312, Name: "dataLinkFrameSize", Type: FieldTypes["unsigned16"]},
+	dictionaryKey{0, 312}: DictionaryEntry{FieldID: 312, Name: "dataLinkFrameSize", Type: FieldTypes["unsigned16"], Units: "octets"},
 	dictionaryKey{0, 313}: DictionaryEntry{FieldID: 313, Name: "ipHeaderPacketSection", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 314}: DictionaryEntry{FieldID: 314, Name: "ipPayloadPacketSection", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 315}: DictionaryEntry{FieldID: 315, Name: "dataLinkFrameSection", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 316}: DictionaryEntry{FieldID: 316, Name: "mplsLabelStackSection", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 317}: DictionaryEntry{FieldID: 317, Name: "mplsPayloadPacketSection", Type: FieldTypes["octetArray"]},
-	dictionaryKey{0, 318}: DictionaryEntry{FieldID: 318, Name: "selectorIdTotalPktsObserved", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 319}: DictionaryEntry{FieldID: 319, Name: "selectorIdTotalPktsSelected", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 318}: DictionaryEntry{FieldID: 318, Name: "selectorIdTotalPktsObserved", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
+	dictionaryKey{0, 319}: DictionaryEntry{FieldID: 319, Name: "selectorIdTotalPktsSelected", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
 	dictionaryKey{0, 320}: DictionaryEntry{FieldID: 320, Name: "absoluteError", Type: FieldTypes["float64"]},
 	dictionaryKey{0, 321}: DictionaryEntry{FieldID: 321, Name: "relativeError", Type: FieldTypes["float64"]},
-	dictionaryKey{0, 322}: DictionaryEntry{FieldID: 322, Name: "observationTimeSeconds", Type: FieldTypes["dateTimeSeconds"]},
-	dictionaryKey{0, 323}: DictionaryEntry{FieldID: 323, Name: "observationTimeMilliseconds", Type: FieldTypes["dateTimeMilliseconds"]},
-	dictionaryKey{0, 324}: DictionaryEntry{FieldID: 324, Name: "observationTimeMicroseconds", Type: FieldTypes["dateTimeMicroseconds"]},
-	dictionaryKey{0, 325}: DictionaryEntry{FieldID: 325, Name: "observationTimeNanoseconds", Type: FieldTypes["dateTimeNanoseconds"]},
+	dictionaryKey{0, 322}: DictionaryEntry{FieldID: 322, Name: "observationTimeSeconds", Type: FieldTypes["dateTimeSeconds"], Units: "seconds"},
+	dictionaryKey{0, 323}: DictionaryEntry{FieldID: 323, Name: "observationTimeMilliseconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
+	dictionaryKey{0, 324}: DictionaryEntry{FieldID: 324, Name: "observationTimeMicroseconds", Type: FieldTypes["dateTimeMicroseconds"], Units: "microseconds"},
+	dictionaryKey{0, 325}: DictionaryEntry{FieldID: 325, Name: "observationTimeNanoseconds", Type: FieldTypes["dateTimeNanoseconds"], Units: "nanoseconds"},
 	dictionaryKey{0, 326}: DictionaryEntry{FieldID: 326, Name: "digestHashValue", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 327}: DictionaryEntry{FieldID: 327, Name: "hashIPPayloadOffset", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 328}: DictionaryEntry{FieldID: 328, Name: "hashIPPayloadSize", Type: FieldTypes["unsigned64"]},
@@ -392,15 +396,15 @@ var builtinIpfixDictionary = fieldDictionary{
 	dictionaryKey{0, 349}: DictionaryEntry{FieldID: 349, Name: "virtualStationUUID", Type: FieldTypes["octetArray"]},
 	dictionaryKey{0, 350}: DictionaryEntry{FieldID: 350, Name: "virtualStationName", Type: FieldTypes["string"]},
 	dictionaryKey{0, 351}: DictionaryEntry{FieldID: 351, Name: "layer2SegmentId", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 352}: DictionaryEntry{FieldID: 352, Name: "layer2OctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 353}: DictionaryEntry{FieldID: 353, Name: "layer2OctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 354}: DictionaryEntry{FieldID: 354, Name: "ingressUnicastPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 355}: DictionaryEntry{FieldID: 355, Name: "ingressMulticastPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 356}: DictionaryEntry{FieldID: 356, Name: "ingressBroadcastPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 357}: DictionaryEntry{FieldID: 357, Name: "egressUnicastPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 358}: DictionaryEntry{FieldID: 358, Name: "egressBroadcastPacketTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 359}: DictionaryEntry{FieldID: 359, Name: "monitoringIntervalStartMilliSeconds", Type: FieldTypes["dateTimeMilliseconds"]},
-	dictionaryKey{0, 360}: DictionaryEntry{FieldID: 360, Name: "monitoringIntervalEndMilliSeconds", Type: FieldTypes["dateTimeMilliseconds"]},
+	dictionaryKey{0, 352}: DictionaryEntry{FieldID: 352, Name: "layer2OctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 353}: DictionaryEntry{FieldID: 353, Name: "layer2OctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 354}: DictionaryEntry{FieldID: 354, Name: "ingressUnicastPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 355}: DictionaryEntry{FieldID: 355, Name: "ingressMulticastPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 356}: DictionaryEntry{FieldID: 356, Name: "ingressBroadcastPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 357}: DictionaryEntry{FieldID: 357, Name: "egressUnicastPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 358}: DictionaryEntry{FieldID: 358, Name: "egressBroadcastPacketTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "packets"},
+	dictionaryKey{0, 359}: DictionaryEntry{FieldID: 359, Name: "monitoringIntervalStartMilliSeconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
+	dictionaryKey{0, 360}: DictionaryEntry{FieldID: 360, Name: "monitoringIntervalEndMilliSeconds", Type: FieldTypes["dateTimeMilliseconds"], Units: "milliseconds"},
 	dictionaryKey{0, 361}: DictionaryEntry{FieldID: 361, Name: "portRangeStart", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 362}: DictionaryEntry{FieldID: 362, Name: "portRangeEnd", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 363}: DictionaryEntry{FieldID: 363, Name: "portRangeStepSize", Type: FieldTypes["unsigned16"]},
@@ -425,53 +429,58 @@ var builtinIpfixDictionary = fieldDictionary{
 	dictionaryKey{0, 382}: DictionaryEntry{FieldID: 382, Name: "distinctCountOfSourceIPv6Address", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 383}: DictionaryEntry{FieldID: 383, Name: "distinctCountOfDestinationIPv6Address", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 384}: DictionaryEntry{FieldID: 384, Name: "valueDistributionMethod", Type: FieldTypes["unsigned8"]},
-	dictionaryKey{0, 385}: DictionaryEntry{FieldID: 385, Name: "rfc3550JitterMilliseconds", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 386}: DictionaryEntry{FieldID: 386, Name: "rfc3550JitterMicroseconds", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 387}: DictionaryEntry{FieldID: 387, Name: "rfc3550JitterNanoseconds", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 385}: DictionaryEntry{FieldID: 385, Name: "rfc3550JitterMilliseconds", Type: FieldTypes["unsigned32"], Units: "milliseconds"},
+	dictionaryKey{0, 386}: DictionaryEntry{FieldID: 386, Name: "rfc3550JitterMicroseconds", Type: FieldTypes["unsigned32"], Units: "microseconds"},
+	dictionaryKey{0, 387}: DictionaryEntry{FieldID: 387, Name: "rfc3550JitterNanoseconds", Type: FieldTypes["unsigned32"], Units: "nanoseconds"},
 	dictionaryKey{0, 388}: DictionaryEntry{FieldID: 388, Name: "dot1qDEI", Type: FieldTypes["boolean"]},
 	dictionaryKey{0, 389}: DictionaryEntry{FieldID: 389, Name: "dot1qCustomerDEI", Type: FieldTypes["boolean"]},
 	dictionaryKey{0, 390}: DictionaryEntry{FieldID: 390, Name: "flowSelectorAlgorithm", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 391}: DictionaryEntry{FieldID: 391, Name: "flowSelectedOctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 392}: DictionaryEntry{FieldID: 392, Name: "flowSelectedPacketDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 393}: DictionaryEntry{FieldID: 393, Name: "flowSelectedFlowDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 394}: DictionaryEntry{FieldID: 394, Name: "selectorIDTotalFlowsObserved", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 395}: DictionaryEntry{FieldID: 395, Name: "selectorIDTotalFlowsSelected", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 391}: DictionaryEntry{FieldID: 391, Name: "flowSelectedOctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 392}: DictionaryEntry{FieldID: 392, Name: "flowSelectedPacketDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "packets"},
+	dictionaryKey{0, 393}: DictionaryEntry{FieldID: 393, Name: "flowSelectedFlowDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "flows"},
+	dictionaryKey{0, 394}: DictionaryEntry{FieldID: 394, Name: "selectorIDTotalFlowsObserved", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
+	dictionaryKey{0, 395}: DictionaryEntry{FieldID: 395, Name: "selectorIDTotalFlowsSelected", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
 	dictionaryKey{0, 396}: DictionaryEntry{FieldID: 396, Name: "samplingFlowInterval", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 397}: DictionaryEntry{FieldID: 397, Name: "samplingFlowSpacing", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 398}: DictionaryEntry{FieldID: 398, Name: "flowSamplingTimeInterval", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 399}: DictionaryEntry{FieldID: 399, Name: "flowSamplingTimeSpacing", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 400}: DictionaryEntry{FieldID: 400, Name: "hashFlowDomain", Type: FieldTypes["unsigned16"]},
-	dictionaryKey{0, 401}: DictionaryEntry{FieldID: 401, Name: "transportOctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 402}: DictionaryEntry{FieldID: 402, Name: "transportPacketDeltaCount", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 401}: DictionaryEntry{FieldID: 401, Name: "transportOctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 402}: DictionaryEntry{FieldID: 402, Name: "transportPacketDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "packets"},
 	dictionaryKey{0, 403}: DictionaryEntry{FieldID: 403, Name: "originalExporterIPv4Address", Type: FieldTypes["ipv4Address"]},
 	dictionaryKey{0, 404}: DictionaryEntry{FieldID: 404, Name: "originalExporterIPv6Address", Type: FieldTypes["ipv6Address"]},
-	dictionaryKey{0, 405}: DictionaryEntry{FieldID: 405, Name: "originalObservationDomainId", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 406}: DictionaryEntry{FieldID: 406, Name: "intermediateProcessId", Type: FieldTypes["unsigned32"]},
-	dictionaryKey{0, 407}: DictionaryEntry{FieldID: 407, Name: "ignoredDataRecordTotalCount", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 405}: DictionaryEntry{FieldID: 405, Name: "originalObservationDomainId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 406}: DictionaryEntry{FieldID: 406, Name: "intermediateProcessId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
+	dictionaryKey{0, 407}: DictionaryEntry{FieldID: 407, Name: "ignoredDataRecordTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter},
 	dictionaryKey{0, 408}: DictionaryEntry{FieldID: 408, Name: "dataLinkFrameType", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 409}: DictionaryEntry{FieldID: 409, Name: "sectionOffset", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 410}: DictionaryEntry{FieldID: 410, Name: "sectionExportedOctets", Type: FieldTypes["unsigned16"]},
 	dictionaryKey{0, 411}: DictionaryEntry{FieldID: 411, Name: "dot1qServiceInstanceTag", Type: FieldTypes["octetArray"]},
-	dictionaryKey{0, 412}: DictionaryEntry{FieldID: 412, Name: "dot1qServiceInstanceId", Type: FieldTypes["unsigned32"]},
+	dictionaryKey{0, 412}: DictionaryEntry{FieldID: 412, Name: "dot1qServiceInstanceId", Type: FieldTypes["unsigned32"], Semantics: Identifier},
 	dictionaryKey{0, 413}: DictionaryEntry{FieldID: 413, Name: "dot1qServiceInstancePriority", Type: FieldTypes["unsigned8"]},
 	dictionaryKey{0, 414}: DictionaryEntry{FieldID: 414, Name: "dot1qCustomerSourceMacAddress", Type: FieldTypes["macAddress"]},
 	dictionaryKey{0, 415}: DictionaryEntry{FieldID: 415, Name: "dot1qCustomerDestinationMacAddress", Type: FieldTypes["macAddress"]},
-	dictionaryKey{0, 417}: DictionaryEntry{FieldID: 417, Name: "postLayer2OctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 418}: DictionaryEntry{FieldID: 418, Name: "postMCastLayer2OctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 420}: DictionaryEntry{FieldID: 420, Name: "postLayer2OctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 421}: DictionaryEntry{FieldID: 421, Name: "postMCastLayer2OctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 422}: DictionaryEntry{FieldID: 422, Name: "minimumLayer2TotalLength", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 423}: DictionaryEntry{FieldID: 423, Name: "maximumLayer2TotalLength", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 424}: DictionaryEntry{FieldID: 424, Name: "droppedLayer2OctetDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 425}: DictionaryEntry{FieldID: 425, Name: "droppedLayer2OctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 426}: DictionaryEntry{FieldID: 426, Name: "ignoredLayer2OctetTotalCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 427}: DictionaryEntry{FieldID: 427, Name: "notSentLayer2OctetTotalCount", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 417}: DictionaryEntry{FieldID: 417, Name: "postLayer2OctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 418}: DictionaryEntry{FieldID: 418, Name: "postMCastLayer2OctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 420}: DictionaryEntry{FieldID: 420, Name: "postLayer2OctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 421}: DictionaryEntry{FieldID: 421, Name: "postMCastLayer2OctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 422}: DictionaryEntry{FieldID: 422, Name: "minimumLayer2TotalLength", Type: FieldTypes["unsigned64"], Units: "octets"},
+	dictionaryKey{0, 423}: DictionaryEntry{FieldID: 423, Name: "maximumLayer2TotalLength", Type: FieldTypes["unsigned64"], Units: "octets"},
+	dictionaryKey{0, 424}: DictionaryEntry{FieldID: 424, Name: "droppedLayer2OctetDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "octets"},
+	dictionaryKey{0, 425}: DictionaryEntry{FieldID: 425, Name: "droppedLayer2OctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 426}: DictionaryEntry{FieldID: 426, Name: "ignoredLayer2OctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
+	dictionaryKey{0, 427}: DictionaryEntry{FieldID: 427, Name: "notSentLayer2OctetTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "octets"},
 	dictionaryKey{0, 428}: DictionaryEntry{FieldID: 428, Name: "layer2OctetDeltaSumOfSquares", Type: FieldTypes["unsigned64"]},
 	dictionaryKey{0, 429}: DictionaryEntry{FieldID: 429, Name: "layer2OctetTotalSumOfSquares", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 430}: DictionaryEntry{FieldID: 430, Name: "layer2FrameDeltaCount", Type: FieldTypes["unsigned64"]},
-	dictionaryKey{0, 431}: DictionaryEntry{FieldID: 431, Name: "layer2FrameTotalCount", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 430}: DictionaryEntry{FieldID: 430, Name: "layer2FrameDeltaCount", Type: FieldTypes["unsigned64"], Semantics: DeltaCounter, Units: "frames"},
+	dictionaryKey{0, 431}: DictionaryEntry{FieldID: 431, Name: "layer2FrameTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "frames"},
 	dictionaryKey{0, 432}: DictionaryEntry{FieldID: 432, Name: "pseudoWireDestinationIPv4Address", Type: FieldTypes["ipv4Address"]},
-	dictionaryKey{0, 433}: DictionaryEntry{FieldID: 433, Name: "ignoredLayer2FrameTotalCount", Type: FieldTypes["unsigned64"]},
+	dictionaryKey{0, 433}: DictionaryEntry{FieldID: 433, Name: "ignoredLayer2FrameTotalCount", Type: FieldTypes["unsigned64"], Semantics: TotalCounter, Units: "frames"},
+
+	// Netflow v7 fields without an IPFIX equivalent, see NetflowV7EnterpriseID
+	dictionaryKey{NetflowV7EnterpriseID, netflowV7FlagsFieldID}:          DictionaryEntry{FieldID: netflowV7FlagsFieldID, EnterpriseID: NetflowV7EnterpriseID, Name: "netflowV7Flags", Type: FieldTypes["unsigned8"]},
+	dictionaryKey{NetflowV7EnterpriseID, netflowV7ExtraFlagsFieldID}:     DictionaryEntry{FieldID: netflowV7ExtraFlagsFieldID, EnterpriseID: NetflowV7EnterpriseID, Name: "netflowV7ExtraFlags", Type: FieldTypes["unsigned16"]},
+	dictionaryKey{NetflowV7EnterpriseID, netflowV7RouterShortcutFieldID}: DictionaryEntry{FieldID: netflowV7RouterShortcutFieldID, EnterpriseID: NetflowV7EnterpriseID, Name: "netflowV7RouterShortcut", Type: FieldTypes["ipv4Address"]},
 }
 
 // IpfixNameLookup looks in the built-in IPFIX dictionary for an entry matching the given
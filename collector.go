@@ -0,0 +1,386 @@
+package ipfix
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"hash/fnv"
+	"io"
+	"net"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrCollectorClosed is returned by Serve and ServePacket once the
+	// Collector has been closed.
+	ErrCollectorClosed = errors.New("collector closed")
+
+	// ErrReusePort is returned by ListenPacketReusePort on platforms without
+	// SO_REUSEPORT.
+	ErrReusePort = errors.New("SO_REUSEPORT not supported")
+)
+
+// A Handler is called by a Collector for every message received. addr is the
+// address of the exporter. The handler is called concurrently for messages
+// from different exporters.
+type Handler func(msg Message, addr net.Addr)
+
+// An ErrorHandler is called by a Collector for errors which do not stop it,
+// such as messages which can not be parsed or failing connections.
+type ErrorHandler func(addr net.Addr, err error)
+
+// A CollectorOption configures a Collector, see NewCollector.
+type CollectorOption func(*Collector)
+
+// WithErrorHandler sets the function errors are reported to. By default they
+// are dropped.
+func WithErrorHandler(h ErrorHandler) CollectorOption {
+	return func(c *Collector) {
+		c.errorHandler = h
+	}
+}
+
+// WithWorkers sets the number of goroutines parsing UDP packets, see
+// ServePacket. The default is GOMAXPROCS.
+func WithWorkers(n int) CollectorOption {
+	return func(c *Collector) {
+		c.workers = n
+	}
+}
+
+// WithQueueLength sets the number of UDP packets each worker may have
+// waiting before further packets are dropped. The default is 1024.
+func WithQueueLength(n int) CollectorOption {
+	return func(c *Collector) {
+		c.queueLength = n
+	}
+}
+
+// CollectorStats holds the counters of a Collector.
+type CollectorStats struct {
+	Received    uint64 // Messages (or UDP packets) received
+	Dropped     uint64 // UDP packets dropped because the workers were busy
+	ParseErrors uint64 // Messages which could not be parsed
+}
+
+// A Collector receives IPFIX messages from exporters and delivers them to a
+// Handler. The messages are parsed with the Collector's Session.
+type Collector struct {
+	stats CollectorStats // accessed atomically, first for alignment
+
+	s                *Session
+	handler          Handler
+	errorHandler     ErrorHandler
+	workers          int
+	queueLength      int
+	peerIdentity     func(*x509.Certificate) string
+	handshakeTimeout time.Duration
+	buffers          sync.Pool
+
+	mut     sync.Mutex
+	closed  bool
+	closers map[io.Closer]struct{}
+	wg      sync.WaitGroup
+}
+
+// NewCollector returns a Collector parsing messages with s and delivering
+// them to h.
+func NewCollector(s *Session, h Handler, opts ...CollectorOption) *Collector {
+	c := &Collector{
+		s:                s,
+		handler:          h,
+		workers:          runtime.GOMAXPROCS(0),
+		queueLength:      1024,
+		handshakeTimeout: 10 * time.Second,
+		closers:          make(map[io.Closer]struct{}),
+	}
+	c.buffers.New = func() interface{} {
+		return make([]byte, 65536)
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.workers < 1 {
+		c.workers = 1
+	}
+	return c
+}
+
+// Stats returns the current counters of the Collector.
+func (c *Collector) Stats() CollectorStats {
+	return CollectorStats{
+		Received:    atomic.LoadUint64(&c.stats.Received),
+		Dropped:     atomic.LoadUint64(&c.stats.Dropped),
+		ParseErrors: atomic.LoadUint64(&c.stats.ParseErrors),
+	}
+}
+
+// Serve accepts IPFIX over TCP connections (RFC 7011 section 10.4) on l.
+// Each connection is read by its own goroutine, and its messages are framed
+// by the length in their header and delivered to the handler in order.
+// Templates are scoped by the remote address of the connection, or the
+// identity of the exporter for TLS, and forgotten when it closes.
+//
+// Serve blocks until accepting fails or the Collector is closed, and returns
+// the error or ErrCollectorClosed. It may be called for several listeners.
+func (c *Collector) Serve(l net.Listener) error {
+	if !c.track(l) {
+		return ErrCollectorClosed
+	}
+	defer c.untrack(l)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if c.isClosed() {
+				return ErrCollectorClosed
+			}
+			return err
+		}
+		if !c.track(conn) {
+			conn.Close()
+			return ErrCollectorClosed
+		}
+		go c.serveConn(conn)
+	}
+}
+
+// serveConn reads and delivers the messages of a connection until it is
+// closed.
+func (c *Collector) serveConn(conn net.Conn) {
+	addr := conn.RemoteAddr()
+	scope := addr
+	defer func() {
+		conn.Close()
+		c.s.ForgetExporter(scope)
+		c.untrack(conn)
+	}()
+
+	cert, err := handshake(conn, c.handshakeTimeout)
+	if err != nil {
+		c.error(addr, err)
+		return
+	}
+	scope = c.scopeAddr(addr, cert)
+
+	d := NewStreamDecoderFrom(c.s, conn, scope)
+	for {
+		msg, err := d.Decode()
+		if err == io.EOF {
+			return
+		} else if _, ok := err.(*ParseError); ok {
+			// The message was framed correctly, so the stream is still usable
+			atomic.AddUint64(&c.stats.Received, 1)
+			atomic.AddUint64(&c.stats.ParseErrors, 1)
+			c.error(addr, err)
+			continue
+		} else if err != nil {
+			if !c.isClosed() {
+				c.error(addr, err)
+			}
+			return
+		}
+		atomic.AddUint64(&c.stats.Received, 1)
+		msg.PeerCertificate = cert
+		c.handler(msg, addr)
+	}
+}
+
+// A packet is a UDP packet waiting for a worker.
+type packet struct {
+	buf  []byte
+	n    int
+	addr net.Addr
+}
+
+// ServePacket receives IPFIX and Netflow packets on the given connections,
+// for example the sockets returned by ListenPacketReusePort. Templates are
+// scoped by the source address of the packets, see ParseBufferFrom.
+//
+// Packets are parsed by a pool of workers, see WithWorkers. All packets from
+// one source are handled by the same worker, so the handler sees them in the
+// order they were received. When the queue of a worker is full, packets are
+// dropped and counted in Stats.
+//
+// ServePacket blocks until ctx is done, reading fails or the Collector is
+// closed, and returns ctx.Err(), the read error or ErrCollectorClosed. The
+// packets already received are handled before it returns. Cancelling ctx
+// does not close the connections.
+func (c *Collector) ServePacket(ctx context.Context, conns ...net.PacketConn) error {
+	for i, conn := range conns {
+		if !c.track(conn) {
+			for _, conn := range conns[:i] {
+				c.untrack(conn)
+			}
+			return ErrCollectorClosed
+		}
+	}
+	defer func() {
+		for _, conn := range conns {
+			c.untrack(conn)
+		}
+	}()
+
+	queues := make([]chan packet, c.workers)
+	var workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan packet, c.queueLength)
+		workers.Add(1)
+		go func(q chan packet) {
+			defer workers.Done()
+			for p := range q {
+				c.handlePacket(p)
+			}
+		}(queues[i])
+	}
+
+	readCtx, cancel := context.WithCancel(ctx)
+	errs := make(chan error, len(conns))
+	var readers sync.WaitGroup
+	for _, conn := range conns {
+		readers.Add(1)
+		go func(conn net.PacketConn) {
+			defer readers.Done()
+			errs <- c.readPackets(conn, queues)
+			cancel()
+		}(conn)
+	}
+
+	// Interrupt the readers once one of them fails or ctx is done
+	interrupted := make(chan struct{})
+	go func() {
+		<-readCtx.Done()
+		for _, conn := range conns {
+			conn.SetReadDeadline(time.Unix(1, 0))
+		}
+		close(interrupted)
+	}()
+	readers.Wait()
+	cancel()
+	<-interrupted
+	for _, conn := range conns {
+		conn.SetReadDeadline(time.Time{})
+	}
+
+	for _, q := range queues {
+		close(q)
+	}
+	workers.Wait()
+
+	err := <-errs
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if c.isClosed() {
+		return ErrCollectorClosed
+	}
+	return err
+}
+
+// readPackets reads packets from conn and queues them for the worker
+// responsible for their source, until reading fails.
+func (c *Collector) readPackets(conn net.PacketConn, queues []chan packet) error {
+	for {
+		buf := c.buffers.Get().([]byte)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			c.buffers.Put(buf)
+			return err
+		}
+		atomic.AddUint64(&c.stats.Received, 1)
+
+		h := fnv.New32a()
+		h.Write([]byte(addr.String()))
+		select {
+		case queues[h.Sum32()%uint32(len(queues))] <- packet{buf: buf, n: n, addr: addr}:
+		default:
+			atomic.AddUint64(&c.stats.Dropped, 1)
+			c.buffers.Put(buf)
+		}
+	}
+}
+
+// handlePacket parses a packet and delivers it to the handler.
+func (c *Collector) handlePacket(p packet) {
+	msg, err := c.s.ParseBufferFrom(p.buf[:p.n], p.addr)
+	c.buffers.Put(p.buf)
+	if err != nil {
+		atomic.AddUint64(&c.stats.ParseErrors, 1)
+		c.error(p.addr, err)
+		return
+	}
+	c.handler(msg, p.addr)
+}
+
+// ListenPacketReusePort opens n sockets bound to the same address with
+// SO_REUSEPORT, so that the kernel spreads the packets of different sources
+// over them. Pass them to ServePacket to read them concurrently. If the port
+// in address is zero, all sockets use the port chosen for the first.
+func ListenPacketReusePort(network, address string, n int) ([]net.PacketConn, error) {
+	lc := net.ListenConfig{Control: setReusePort}
+	var conns []net.PacketConn
+	for i := 0; i < n; i++ {
+		conn, err := lc.ListenPacket(context.Background(), network, address)
+		if err != nil {
+			for _, conn := range conns {
+				conn.Close()
+			}
+			return nil, err
+		}
+		conns = append(conns, conn)
+		address = conn.LocalAddr().String()
+	}
+	return conns, nil
+}
+
+func (c *Collector) error(addr net.Addr, err error) {
+	if debug {
+		dl.Printf("collector: %v: %v", addr, err)
+	}
+	if c.errorHandler != nil {
+		c.errorHandler(addr, err)
+	}
+}
+
+// track registers a listener or connection, so that Close can close it and
+// wait until it is no longer used. It returns false if the Collector is
+// already closed.
+func (c *Collector) track(cl io.Closer) bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.closed {
+		return false
+	}
+	c.closers[cl] = struct{}{}
+	c.wg.Add(1)
+	return true
+}
+
+func (c *Collector) untrack(cl io.Closer) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	delete(c.closers, cl)
+	c.wg.Done()
+}
+
+func (c *Collector) isClosed() bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.closed
+}
+
+// Close stops the Collector. It closes all listeners and connections and
+// waits for the handlers to return.
+func (c *Collector) Close() error {
+	c.mut.Lock()
+	c.closed = true
+	for cl := range c.closers {
+		cl.Close()
+	}
+	c.mut.Unlock()
+
+	c.wg.Wait()
+	return nil
+}
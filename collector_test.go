@@ -0,0 +1,208 @@
+package ipfix
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type collected struct {
+	msg  Message
+	addr net.Addr
+}
+
+func TestCollectorTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := make(chan collected, 16)
+	s := NewSession()
+	c := NewCollector(s, func(msg Message, addr net.Addr) {
+		msgs <- collected{msg, addr}
+	})
+	served := make(chan error, 1)
+	go func() {
+		served <- c.Serve(l)
+	}()
+
+	dataA := streamIPFIX[len(streamIPFIX)-16:]
+	hdrA := []byte{0x00, 0x0a, 0x00, 0x20, 0x56, 0x85, 0xb3, 0x70, 0, 0, 0, 1, 0, 0, 0, 1}
+	connA, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := connA.Write(append(append([]byte(nil), streamIPFIX...), append(hdrA, dataA...)...)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got := <-msgs
+		if got.addr.String() != connA.LocalAddr().String() {
+			t.Error("Incorrect exporter address", got.addr)
+		}
+		if len(got.msg.DataRecords) != 1 {
+			t.Error("Incorrect number of data records", len(got.msg.DataRecords))
+		}
+	}
+
+	// Templates are not shared between connections
+	connB, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := connB.Write(append(hdrA, dataA...)); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-msgs; len(got.msg.DataRecords) != 0 {
+		t.Error("Incorrect number of data records", len(got.msg.DataRecords))
+	}
+
+	// ... and forgotten when the connection closes
+	scope := TemplateScope{Exporter: connA.LocalAddr().String(), DomainID: 1}
+	if _, ok := s.TemplateRefreshed(scope, 256); !ok {
+		t.Error("Template not known")
+	}
+	connA.Close()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if _, ok := s.TemplateRefreshed(scope, 256); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Template not forgotten")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	c.Close()
+	if err := <-served; err != ErrCollectorClosed {
+		t.Error("Expected ErrCollectorClosed, got", err)
+	}
+	connB.Close()
+}
+
+func TestCollectorUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var mut sync.Mutex
+	seqs := make(map[string][]uint32)
+	received := make(chan struct{}, 64)
+	c := NewCollector(NewSession(), func(msg Message, addr net.Addr) {
+		mut.Lock()
+		seqs[addr.String()] = append(seqs[addr.String()], msg.Header.SequenceNumber)
+		mut.Unlock()
+		received <- struct{}{}
+	}, WithWorkers(4))
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- c.ServePacket(ctx, conn)
+	}()
+
+	const perExporter = 10
+	var exporters []net.Conn
+	for i := 0; i < 3; i++ {
+		e, err := net.Dial("udp", conn.LocalAddr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer e.Close()
+		exporters = append(exporters, e)
+	}
+	for seq := 0; seq < perExporter; seq++ {
+		for _, e := range exporters {
+			pkt := append([]byte(nil), streamIPFIX...)
+			binary.BigEndian.PutUint32(pkt[8:], uint32(seq))
+			if _, err := e.Write(pkt); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for i := 0; i < perExporter*len(exporters); i++ {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout waiting for messages")
+		}
+	}
+
+	mut.Lock()
+	for addr, s := range seqs {
+		for i := range s {
+			if s[i] != uint32(i) {
+				t.Errorf("Messages from %s out of order: %v", addr, s)
+				break
+			}
+		}
+	}
+	mut.Unlock()
+
+	// A packet which can not be parsed
+	if _, err := exporters[0].Write([]byte{0, 4, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); c.Stats().ParseErrors == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("Parse error not counted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if st := c.Stats(); st.Received != perExporter*uint64(len(exporters))+1 || st.Dropped != 0 || st.ParseErrors != 1 {
+		t.Errorf("Incorrect stats %+v", st)
+	}
+
+	cancel()
+	if err := <-served; err != context.Canceled {
+		t.Error("Expected context.Canceled, got", err)
+	}
+}
+
+func TestListenPacketReusePort(t *testing.T) {
+	conns, err := ListenPacketReusePort("udp", "127.0.0.1:0", 2)
+	if err == ErrReusePort {
+		t.Skip(err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	if len(conns) != 2 || conns[0].LocalAddr().String() != conns[1].LocalAddr().String() {
+		t.Fatal("Incorrect sockets", conns)
+	}
+
+	received := make(chan struct{}, 1)
+	c := NewCollector(NewSession(), func(Message, net.Addr) {
+		received <- struct{}{}
+	})
+	served := make(chan error, 1)
+	go func() {
+		served <- c.ServePacket(context.Background(), conns...)
+	}()
+	e, err := net.Dial("udp", conns[0].LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	if _, err := e.Write(streamIPFIX); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for message")
+	}
+
+	c.Close()
+	if err := <-served; err != ErrCollectorClosed {
+		t.Error("Expected ErrCollectorClosed, got", err)
+	}
+}
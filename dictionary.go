@@ -0,0 +1,456 @@
+package ipfix
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/gcfg.v1"
+)
+
+// ErrDictionaryFormat is returned when a dictionary file cannot be read,
+// wrapped with a description of the problem.
+var ErrDictionaryFormat = errors.New("invalid dictionary")
+
+// A DictionaryConflict reports a DictionaryEntry which replaced a different
+// entry with the same enterprise and field IDs, or which has the same name as
+// an entry with other IDs.
+type DictionaryConflict struct {
+	Entry    DictionaryEntry // the entry added
+	Existing DictionaryEntry // the entry replaced or shadowed
+}
+
+func (c DictionaryConflict) String() string {
+	return fmt.Sprintf("%s (%d/%d) conflicts with %s (%d/%d)",
+		c.Entry.Name, c.Entry.EnterpriseID, c.Entry.FieldID,
+		c.Existing.Name, c.Existing.EnterpriseID, c.Existing.FieldID)
+}
+
+// A Dictionary maps enterprise and field IDs onto DictionaryEntries. It is
+// immutable: With returns a new Dictionary with the entries overlaid on
+// those of the original, which is left unchanged. Dictionaries can thus be
+// shared by Interpreters and goroutines, each adding its own vendor fields.
+type Dictionary struct {
+	base    fieldDictionary            // shared, never modified
+	symbols map[dictionaryKey]*Symbols // of the base entries, shared
+	overlay fieldDictionary            // copied on write
+}
+
+var (
+	ipfixDictionary     = &Dictionary{base: builtinIpfixDictionary, symbols: builtinIpfixSymbols}
+	netflowV9Dictionary = &Dictionary{base: builtinNetflowV9Dictionary, symbols: builtinNetflowV9Symbols}
+)
+
+// IpfixDictionary returns the builtin dictionary of the IANA information
+// elements, used by the Interpreters of IPFIX and fixed format Netflow.
+func IpfixDictionary() *Dictionary {
+	return ipfixDictionary
+}
+
+// NetflowV9Dictionary returns the builtin dictionary of the Netflow v9 field
+// types, used by the Interpreters of Netflow v9.
+func NetflowV9Dictionary() *Dictionary {
+	return netflowV9Dictionary
+}
+
+// Lookup returns the entry of an enterprise and field ID.
+func (d *Dictionary) Lookup(enterpriseID uint32, fieldID uint16) (DictionaryEntry, bool) {
+	return d.lookup(dictionaryKey{enterpriseID, fieldID})
+}
+
+func (d *Dictionary) lookup(key dictionaryKey) (DictionaryEntry, bool) {
+	if e, ok := d.overlay[key]; ok {
+		return e, true
+	}
+	e, ok := d.base[key]
+	if ok {
+		e.Symbols = d.symbols[key]
+	}
+	return e, ok
+}
+
+// With returns a Dictionary with the entries added to those of d, replacing
+// the entries with the same enterprise and field IDs.
+func (d *Dictionary) With(es ...DictionaryEntry) *Dictionary {
+	overlay := make(fieldDictionary, len(d.overlay)+len(es))
+	for key, e := range d.overlay {
+		overlay[key] = e
+	}
+	for _, e := range es {
+		overlay[dictionaryKey{e.EnterpriseID, e.FieldID}] = e
+	}
+	return &Dictionary{base: d.base, symbols: d.symbols, overlay: overlay}
+}
+
+// Conflicts returns the conflicts that adding the entries to d with With
+// would cause, with the entries of d or those before in es. Entries
+// identical to those they replace are not conflicts.
+func (d *Dictionary) Conflicts(es []DictionaryEntry) []DictionaryConflict {
+	names := make(map[string]dictionaryKey, len(d.base)+len(d.overlay))
+	for key, e := range d.base {
+		names[e.Name] = key
+	}
+	for key, e := range d.overlay {
+		if old, ok := d.base[key]; ok && names[old.Name] == key {
+			delete(names, old.Name)
+		}
+		names[e.Name] = key
+	}
+
+	added := make(fieldDictionary, len(es))
+	lookup := func(key dictionaryKey) (DictionaryEntry, bool) {
+		if e, ok := added[key]; ok {
+			return e, true
+		}
+		return d.lookup(key)
+	}
+
+	var conflicts []DictionaryConflict
+	for _, e := range es {
+		key := dictionaryKey{e.EnterpriseID, e.FieldID}
+		if old, ok := lookup(key); ok && !sameEntry(old, e) {
+			conflicts = append(conflicts, DictionaryConflict{Entry: e, Existing: old})
+		} else if other, ok := names[e.Name]; ok && other != key {
+			existing, _ := lookup(other)
+			conflicts = append(conflicts, DictionaryConflict{Entry: e, Existing: existing})
+		}
+		added[key] = e
+		names[e.Name] = key
+	}
+	return conflicts
+}
+
+// sameEntry reports whether two entries are identical, comparing their
+// Symbols by content rather than by pointer.
+func sameEntry(a, b DictionaryEntry) bool {
+	as, bs := a.Symbols, b.Symbols
+	a.Symbols, b.Symbols = nil, nil
+	if a != b {
+		return false
+	}
+	if as == bs {
+		return true
+	}
+	return as != nil && bs != nil && as.decoder == nil && bs.decoder == nil &&
+		reflect.DeepEqual(as.names, bs.names) && reflect.DeepEqual(as.flags, bs.flags)
+}
+
+// AddDictionaryEntries adds the entries to the dictionary used by
+// Interpret, as AddDictionaryEntry does, and returns the conflicts with the
+// entries already in the dictionary or added before in the same call, see
+// Dictionary.Conflicts.
+func (i *Interpreter) AddDictionaryEntries(es []DictionaryEntry) []DictionaryConflict {
+	i.mut.Lock()
+	defer i.mut.Unlock()
+	conflicts := i.dictionary.Conflicts(es)
+	i.dictionary = i.dictionary.With(es...)
+	return conflicts
+}
+
+// ReadIANADictionary reads the information elements of a registry in the
+// format of the IANA ipfix.xml, as published at
+// https://www.iana.org/assignments/ipfix/ipfix.xml, with the given
+// enterprise ID. Vendors publish their elements in this format too. The list
+// elements of RFC 6313, such as basicList, are read with the type Unknown.
+func ReadIANADictionary(r io.Reader, enterpriseID uint32) ([]DictionaryEntry, error) {
+	var root struct {
+		Registries []struct {
+			ID      string `xml:"id,attr"`
+			Records []struct {
+				Name              string    `xml:"name"`
+				ElementID         string    `xml:"elementId"`
+				DataType          string    `xml:"dataType"`
+				DataTypeSemantics Semantics `xml:"dataTypeSemantics"`
+				Units             string    `xml:"units"`
+				Range             string    `xml:"range"`
+				Status            string    `xml:"status"`
+			} `xml:"record"`
+		} `xml:"registry"`
+	}
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDictionaryFormat, err)
+	}
+
+	var es []DictionaryEntry
+	for _, reg := range root.Registries {
+		if reg.ID != "ipfix-information-elements" {
+			continue
+		}
+		for _, rec := range reg.Records {
+			name, dataType := strings.TrimSpace(rec.Name), strings.TrimSpace(rec.DataType)
+			if name == "" || dataType == "" {
+				// reserved and unassigned ranges
+				continue
+			}
+			id, err := strconv.ParseUint(strings.TrimSpace(rec.ElementID), 10, 15)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: elementId: %v", ErrDictionaryFormat, name, err)
+			}
+			t, ok := FieldTypes[dataType]
+			if !ok && !ianaListTypes[dataType] {
+				return nil, fmt.Errorf("%w: %s: unknown dataType %q", ErrDictionaryFormat, name, dataType)
+			}
+			e := DictionaryEntry{
+				Name:         name,
+				FieldID:      uint16(id),
+				EnterpriseID: enterpriseID,
+				Type:         t,
+				Semantics:    rec.DataTypeSemantics,
+				Units:        strings.TrimSpace(rec.Units),
+				Range:        parseRange(rec.Range),
+				Status:       strings.TrimSpace(rec.Status),
+			}
+			if e.Units == "none" {
+				e.Units = ""
+			}
+			if e.Status == "current" {
+				e.Status = ""
+			}
+			es = append(es, e)
+		}
+		return es, nil
+	}
+	return nil, fmt.Errorf("%w: no ipfix-information-elements registry", ErrDictionaryFormat)
+}
+
+// parseRange parses an IANA range such as "0-63", returning the zero
+// ValueRange if it is not one.
+func parseRange(s string) ValueRange {
+	bounds := strings.SplitN(strings.TrimSpace(s), "-", 2)
+	if len(bounds) != 2 {
+		return ValueRange{}
+	}
+	min, err1 := strconv.ParseUint(strings.TrimSpace(bounds[0]), 0, 64)
+	max, err2 := strconv.ParseUint(strings.TrimSpace(bounds[1]), 0, 64)
+	if err1 != nil || err2 != nil {
+		return ValueRange{}
+	}
+	return ValueRange{min, max}
+}
+
+// ianaListTypes are the structured data types of RFC 6313, which are not
+// decoded. Their elements are read with the type Unknown, as in the builtin
+// dictionary, and interpreted as raw bytes.
+var ianaListTypes = map[string]bool{
+	"basicList":            true,
+	"subTemplateList":      true,
+	"subTemplateMultiList": true,
+}
+
+// ReadNetflowV9Dictionary reads Netflow v9 field types in the CSV layout of
+// etc/nfv9-fields.txt: lines of name, field type and length. The length is
+// N for variable-length counters, and may also be an IPFIX data type name,
+// such as ipv4Address, where the length alone is ambiguous: a length of 4 is
+// read as an unsigned integer, so IPv4 address fields must name their type.
+// Lengths of 6 and 16 are read as MAC and IPv6 addresses, other lengths as
+// unsigned integers.
+func ReadNetflowV9Dictionary(r io.Reader) ([]DictionaryEntry, error) {
+	rdr := csv.NewReader(r)
+	rdr.FieldsPerRecord = 3
+	rdr.TrimLeadingSpace = true
+	rdr.Comment = '#'
+
+	var es []DictionaryEntry
+	for {
+		rec, err := rdr.Read()
+		if err == io.EOF {
+			return es, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDictionaryFormat, err)
+		}
+		id, err := strconv.ParseUint(rec[1], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: field type: %v", ErrDictionaryFormat, rec[0], err)
+		}
+		t, ok := nfv9FieldType(rec[2])
+		if !ok {
+			return nil, fmt.Errorf("%w: %s: unknown length %q", ErrDictionaryFormat, rec[0], rec[2])
+		}
+		es = append(es, DictionaryEntry{Name: rec[0], FieldID: uint16(id), Type: t})
+	}
+}
+
+// nfv9FieldType returns the type of a field from the length column of the
+// Netflow v9 CSV layout.
+func nfv9FieldType(length string) (FieldType, bool) {
+	switch length {
+	case "N":
+		return VarInt, true
+	case "1":
+		return Uint8, true
+	case "2":
+		return Uint16, true
+	case "3":
+		return Uint24, true
+	case "4":
+		return Uint32, true
+	case "6":
+		return MacAddress, true
+	case "8":
+		return Uint64, true
+	case "16":
+		return Ipv6Address, true
+	}
+	t, ok := FieldTypes[length]
+	return t, ok
+}
+
+// ReadUserDictionary reads fields in the gcfg (INI-like) format, with one
+// section per field:
+//
+//	[field "someVendorField"]
+//	id = 42
+//	enterprise = 123456
+//	type = signed32
+//	semantics = deltaCounter
+//	units = octets
+//
+// Semantics, units and status are optional. The values of an enumerated
+// field are named by "enum" variables, and the bits of a bitfield by "flag"
+// variables, each holding a number followed by the name:
+//
+//	[field "someVendorAction"]
+//	id = 44
+//	enterprise = 123456
+//	type = unsigned8
+//	enum = 0 permit
+//	enum = 1 deny
+//
+// with the bits of flags numbered from 0, the least significant. See
+// NewEnumSymbols and NewFlagSymbols.
+func ReadUserDictionary(r io.Reader) ([]DictionaryEntry, error) {
+	var dict userDictionary
+	if err := gcfg.ReadInto(&dict, r); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDictionaryFormat, err)
+	}
+
+	names := make([]string, 0, len(dict.Field))
+	for name := range dict.Field {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	es := make([]DictionaryEntry, 0, len(names))
+	for _, name := range names {
+		f := dict.Field[name]
+		t, ok := FieldTypes[f.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s: unknown type %q", ErrDictionaryFormat, name, f.Type)
+		}
+		sem, ok := SemanticsNames[f.Semantics]
+		if !ok && f.Semantics != "" {
+			return nil, fmt.Errorf("%w: %s: unknown semantics %q", ErrDictionaryFormat, name, f.Semantics)
+		}
+		syms, err := f.symbols()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrDictionaryFormat, name, err)
+		}
+		es = append(es, DictionaryEntry{
+			Name:         name,
+			FieldID:      f.ID,
+			EnterpriseID: f.Enterprise,
+			Type:         t,
+			Semantics:    sem,
+			Units:        f.Units,
+			Status:       f.Status,
+			Symbols:      syms,
+		})
+	}
+	return es, nil
+}
+
+type userDictionary struct {
+	Field map[string]*userField
+}
+
+type userField struct {
+	ID         uint16
+	Enterprise uint32
+	Type       string
+	Semantics  string
+	Units      string
+	Status     string
+	Enum       []string
+	Flag       []string
+}
+
+// symbols returns the Symbols of the enum or flag variables of the field, or
+// nil if it has none.
+func (f *userField) symbols() (*Symbols, error) {
+	switch {
+	case len(f.Enum) > 0 && len(f.Flag) > 0:
+		return nil, errors.New("both enum and flag given")
+	case len(f.Enum) > 0:
+		names := make(map[uint64]string, len(f.Enum))
+		for _, v := range f.Enum {
+			n, name, err := splitSymbol(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("enum %q: %v", v, err)
+			}
+			names[n] = name
+		}
+		return NewEnumSymbols(names), nil
+	case len(f.Flag) > 0:
+		var names []string
+		for _, v := range f.Flag {
+			n, name, err := splitSymbol(v, 6)
+			if err != nil {
+				return nil, fmt.Errorf("flag %q: %v", v, err)
+			}
+			for uint64(len(names)) <= n {
+				names = append(names, "")
+			}
+			names[n] = name
+		}
+		return NewFlagSymbols(names), nil
+	}
+	return nil, nil
+}
+
+// splitSymbol splits an enum or flag variable into its number, of at most
+// the given bit size, and its name.
+func splitSymbol(v string, bitSize int) (uint64, string, error) {
+	i := strings.IndexByte(v, ' ')
+	if i < 0 {
+		return 0, "", errors.New("missing name")
+	}
+	n, err := strconv.ParseUint(v[:i], 0, bitSize)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, strings.TrimSpace(v[i+1:]), nil
+}
+
+// ReadDictionaryFile reads a dictionary file in the format given by its
+// extension: the IANA XML format for ".xml" files, with enterprise ID 0, the
+// Netflow v9 CSV layout for ".csv" and ".txt" files, and the gcfg format
+// otherwise.
+func ReadDictionaryFile(name string) ([]DictionaryEntry, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var es []DictionaryEntry
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".xml":
+		es, err = ReadIANADictionary(f, 0)
+	case ".csv", ".txt":
+		es, err = ReadNetflowV9Dictionary(f)
+	default:
+		es, err = ReadUserDictionary(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return es, nil
+}
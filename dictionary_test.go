@@ -0,0 +1,295 @@
+package ipfix
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const ianaRegistry = `<?xml version='1.0' encoding='UTF-8'?>
+<registry xmlns="http://www.iana.org/assignments" id="ipfix">
+  <title>IP Flow Information Export (IPFIX) Entities</title>
+  <registry id="ipfix-information-elements">
+    <record>
+      <name>octetDeltaCount</name>
+      <dataType>unsigned64</dataType>
+      <dataTypeSemantics>deltaCounter</dataTypeSemantics>
+      <elementId>1</elementId>
+      <status>current</status>
+      <units>octets</units>
+    </record>
+    <record>
+      <name>ipDiffServCodePoint</name>
+      <dataType>unsigned8</dataType>
+      <dataTypeSemantics>identifier</dataTypeSemantics>
+      <elementId>195</elementId>
+      <status>current</status>
+      <range>0-63</range>
+    </record>
+    <record>
+      <name>samplerId</name>
+      <dataType>unsigned8</dataType>
+      <dataTypeSemantics>identifier</dataTypeSemantics>
+      <elementId>48</elementId>
+      <status>deprecated</status>
+    </record>
+    <record>
+      <name>basicList</name>
+      <dataType>basicList</dataType>
+      <dataTypeSemantics>list</dataTypeSemantics>
+      <elementId>291</elementId>
+      <status>current</status>
+    </record>
+    <record>
+      <elementId>434-32767</elementId>
+      <status>current</status>
+    </record>
+  </registry>
+  <registry id="ipfix-structured-data-types-semantics">
+    <record>
+      <name>noneOf</name>
+      <value>0x00</value>
+    </record>
+  </registry>
+</registry>
+`
+
+func TestReadIANADictionary(t *testing.T) {
+	es, err := ReadIANADictionary(strings.NewReader(ianaRegistry), 123456)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DictionaryEntry{
+		{Name: "octetDeltaCount", FieldID: 1, EnterpriseID: 123456, Type: Uint64, Semantics: DeltaCounter, Units: "octets"},
+		{Name: "ipDiffServCodePoint", FieldID: 195, EnterpriseID: 123456, Type: Uint8, Semantics: Identifier, Range: ValueRange{0, 63}},
+		{Name: "samplerId", FieldID: 48, EnterpriseID: 123456, Type: Uint8, Semantics: Identifier, Status: "deprecated"},
+		{Name: "basicList", FieldID: 291, EnterpriseID: 123456, Type: Unknown, Semantics: List},
+	}
+	if len(es) != len(expected) {
+		t.Fatal("Incorrect number of entries", len(es))
+	}
+	for i := range es {
+		if es[i] != expected[i] {
+			t.Errorf("Incorrect entry %v != %v", es[i], expected[i])
+		}
+	}
+
+	_, err = ReadIANADictionary(strings.NewReader(`<registry id="ipfix"></registry>`), 0)
+	if !errors.Is(err, ErrDictionaryFormat) {
+		t.Error("Incorrect error for missing registry", err)
+	}
+}
+
+func TestReadNetflowV9Dictionary(t *testing.T) {
+	f, err := os.Open("etc/nfv9-fields.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	es, err := ReadNetflowV9Dictionary(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != len(builtinNetflowV9Dictionary) {
+		t.Error("Incorrect number of entries", len(es))
+	}
+	for _, e := range es {
+		builtin := builtinNetflowV9Dictionary[dictionaryKey{0, e.FieldID}]
+		if e != builtin {
+			t.Errorf("Incorrect entry for field type %d: %v != %v", e.FieldID, e, builtin)
+		}
+	}
+
+	es, err = ReadNetflowV9Dictionary(strings.NewReader("# vendor fields\nNEXT_HOP,32769,ipv4Address\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 1 || es[0] != (DictionaryEntry{Name: "NEXT_HOP", FieldID: 32769, Type: Ipv4Address}) {
+		t.Error("Incorrect entries", es)
+	}
+
+	_, err = ReadNetflowV9Dictionary(strings.NewReader("NEXT_HOP,32769,5\n"))
+	if !errors.Is(err, ErrDictionaryFormat) {
+		t.Error("Incorrect error for bad length", err)
+	}
+}
+
+func TestReadUserDictionary(t *testing.T) {
+	es, err := ReadUserDictionary(strings.NewReader(`
+[field "someVendorField"]
+id = 42
+enterprise = 123456
+type = signed32
+
+[field "someVendorCounter"]
+id = 43
+enterprise = 123456
+type = unsigned64
+semantics = totalCounter
+units = packets
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DictionaryEntry{
+		{Name: "someVendorCounter", FieldID: 43, EnterpriseID: 123456, Type: Uint64, Semantics: TotalCounter, Units: "packets"},
+		{Name: "someVendorField", FieldID: 42, EnterpriseID: 123456, Type: Int32},
+	}
+	if len(es) != len(expected) {
+		t.Fatal("Incorrect number of entries", len(es))
+	}
+	for i := range es {
+		if es[i] != expected[i] {
+			t.Errorf("Incorrect entry %v != %v", es[i], expected[i])
+		}
+	}
+
+	_, err = ReadUserDictionary(strings.NewReader("[field \"x\"]\nid = 1\ntype = integer\n"))
+	if !errors.Is(err, ErrDictionaryFormat) {
+		t.Error("Incorrect error for bad type", err)
+	}
+}
+
+func TestReadUserDictionarySymbols(t *testing.T) {
+	es, err := ReadUserDictionary(strings.NewReader(`
+[field "someVendorAction"]
+id = 44
+enterprise = 123456
+type = unsigned8
+enum = 0 permit
+enum = 1 deny
+
+[field "someVendorFlags"]
+id = 45
+enterprise = 123456
+type = unsigned8
+flag = 0 inbound
+flag = 2 logged
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 2 {
+		t.Fatal("Incorrect number of entries", len(es))
+	}
+	if v := fmt.Sprint(es[0].Symbols.Value(1)); v != "deny" {
+		t.Error("Incorrect enum value", v)
+	}
+	if v := fmt.Sprint(es[1].Symbols.Value(0x07)); v != "inbound|logged|0x2" {
+		t.Error("Incorrect flags value", v)
+	}
+
+	for _, v := range []string{"enum = deny", "enum = x deny", "flag = 64 logged", "enum = 0 permit\nflag = 0 inbound"} {
+		_, err := ReadUserDictionary(strings.NewReader("[field \"x\"]\nid = 1\ntype = unsigned8\n" + v + "\n"))
+		if !errors.Is(err, ErrDictionaryFormat) {
+			t.Errorf("Incorrect error for %q: %v", v, err)
+		}
+	}
+}
+
+func TestReadDictionaryFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipfix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "vendor.xml")
+	if err := ioutil.WriteFile(name, []byte(ianaRegistry), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if es, err := ReadDictionaryFile(name); err != nil || len(es) != 4 {
+		t.Error("Incorrect IANA dictionary", len(es), err)
+	}
+
+	name = filepath.Join(dir, "vendor.ini")
+	if err := ioutil.WriteFile(name, []byte("[field \"x\"]\nid = 1\ntype = unsigned8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if es, err := ReadDictionaryFile(name); err != nil || len(es) != 1 {
+		t.Error("Incorrect user dictionary", len(es), err)
+	}
+
+	if _, err := ReadDictionaryFile(filepath.Join(dir, "missing.ini")); !os.IsNotExist(err) {
+		t.Error("Incorrect error for missing file", err)
+	}
+}
+
+func TestAddDictionaryEntries(t *testing.T) {
+	dict := &Dictionary{base: fieldDictionary{
+		dictionaryKey{0, 1}: {Name: "octetDeltaCount", FieldID: 1, Type: Uint64},
+		dictionaryKey{0, 2}: {Name: "packetDeltaCount", FieldID: 2, Type: Uint64},
+	}}
+	i := NewInterpreter(NewSession(), WithDictionary(dict))
+
+	conflicts := i.AddDictionaryEntries([]DictionaryEntry{
+		{Name: "octetDeltaCount", FieldID: 1, Type: Uint64},
+		{Name: "packetCount", FieldID: 2, Type: Uint32},
+		{Name: "octetDeltaCount", EnterpriseID: 123456, FieldID: 1, Type: Uint64},
+		{Name: "vendorField", EnterpriseID: 123456, FieldID: 2, Type: Uint8},
+		{Name: "vendorField", EnterpriseID: 123456, FieldID: 3, Type: Uint8},
+	})
+	if len(conflicts) != 3 {
+		t.Fatal("Incorrect number of conflicts", conflicts)
+	}
+	if c := conflicts[0]; c.Entry.Name != "packetCount" || c.Existing.Name != "packetDeltaCount" {
+		t.Error("Incorrect conflict", c)
+	}
+	if c := conflicts[1]; c.Entry.EnterpriseID != 123456 || c.Existing.EnterpriseID != 0 {
+		t.Error("Incorrect conflict", c)
+	}
+	if c := conflicts[2]; c.Entry.FieldID != 3 || c.Existing.FieldID != 2 {
+		t.Error("Incorrect conflict", c)
+	}
+
+	if e, _ := i.Dictionary().Lookup(0, 2); e.Name != "packetCount" {
+		t.Error("Incorrect replaced entry", e)
+	}
+	if e, _ := i.Dictionary().Lookup(123456, 2); e.Name != "vendorField" {
+		t.Error("Incorrect added entry", e)
+	}
+	if e, _ := dict.Lookup(0, 2); e.Name != "packetDeltaCount" {
+		t.Error("Incorrect entry of the original dictionary", e)
+	}
+	if _, ok := dict.Lookup(123456, 2); ok {
+		t.Error("Entry added to the original dictionary")
+	}
+}
+
+func TestInterpreterDictionaryIsolation(t *testing.T) {
+	s := NewSession()
+	s.LoadTemplateRecords([]TemplateRecord{{
+		TemplateID:      256,
+		FieldSpecifiers: []TemplateFieldSpecifier{{EnterpriseID: 123456, FieldID: 42, Length: 4}},
+	}})
+	rec := DataRecord{TemplateID: 256, Fields: [][]byte{{0xff, 0xff, 0xff, 0xfe}}}
+
+	i1 := NewInterpreter(s)
+	i2 := NewInterpreter(s)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			i1.Interpret(rec)
+		}
+	}()
+	i1.AddDictionaryEntry(DictionaryEntry{Name: "someVendorField", FieldID: 42, EnterpriseID: 123456, Type: Int32})
+	wg.Wait()
+
+	if f := i1.Interpret(rec)[0]; f.Name != "someVendorField" || f.Value != int32(-2) {
+		t.Error("Incorrect field with the added entry", f.Name, f.Value)
+	}
+	if f := i2.Interpret(rec)[0]; f.Name != "" || f.Value != nil {
+		t.Error("Incorrect field of the other interpreter", f.Name, f.Value)
+	}
+	if _, ok := IpfixDictionary().Lookup(123456, 42); ok {
+		t.Error("Entry added to the builtin dictionary")
+	}
+}
@@ -0,0 +1,286 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUnmappable is returned by a Downconverter with the FailUnmappable
+// policy when a field cannot be expressed in Netflow v9.
+var ErrUnmappable = errors.New("field cannot be expressed in Netflow v9")
+
+// An UnmappablePolicy decides what a Downconverter does with the fields which
+// cannot be expressed in Netflow v9: variable-length fields, and
+// enterprise-specific fields other than those of Cisco.
+type UnmappablePolicy int
+
+const (
+	// DropUnmappable removes the fields from the templates and records.
+	// Templates left without fields are dropped with their records.
+	DropUnmappable UnmappablePolicy = iota
+	// FailUnmappable makes Downconvert return ErrUnmappable.
+	FailUnmappable
+)
+
+// A DownconverterOption configures a Downconverter, see NewDownconverter.
+type DownconverterOption func(*Downconverter)
+
+// WithUnmappable sets the policy for fields which cannot be expressed in
+// Netflow v9. The default is DropUnmappable.
+func WithUnmappable(p UnmappablePolicy) DownconverterOption {
+	return func(d *Downconverter) {
+		d.policy = p
+	}
+}
+
+// ipfixTimeFields maps the IPFIX information elements holding absolute flow
+// start and end times onto their Netflow v9 field type and IPFIX data type.
+var ipfixTimeFields = map[uint16]struct {
+	nfv9 uint16
+	t    FieldType
+}{
+	150: {nfv9FirstSwitched, DateTimeSeconds},      // flowStartSeconds
+	151: {nfv9LastSwitched, DateTimeSeconds},       // flowEndSeconds
+	152: {nfv9FirstSwitched, DateTimeMilliseconds}, // flowStartMilliseconds
+	153: {nfv9LastSwitched, DateTimeMilliseconds},  // flowEndMilliseconds
+	154: {nfv9FirstSwitched, DateTimeMicroseconds}, // flowStartMicroseconds
+	155: {nfv9LastSwitched, DateTimeMicroseconds},  // flowEndMicroseconds
+	156: {nfv9FirstSwitched, DateTimeNanoseconds},  // flowStartNanoseconds
+	157: {nfv9LastSwitched, DateTimeNanoseconds},   // flowEndNanoseconds
+}
+
+// ipfixScopeFields maps IPFIX information elements onto the scope field types
+// of Netflow v9 options templates, the reverse of nfv9ScopeFields.
+var ipfixScopeFields = map[uint16]uint16{
+	144: 1, // exportingProcessId: System
+	10:  2, // ingressInterface: Interface
+	141: 3, // lineCardId: Line Card
+	143: 4, // meteringProcessId: Cache
+	145: 5, // templateId: Template
+}
+
+// A Downconverter converts IPFIX messages into Netflow v9 messages for
+// consumers which do not support IPFIX. It reverses the mapping of the
+// Translator: information elements are kept as the Netflow v9 field types
+// with the same ID, Cisco enterprise-specific elements become the vendor
+// field types above 32767, and absolute flow start and end times become
+// FIRST_SWITCHED and LAST_SWITCHED, relative to a SysUptime counted from the
+// creation of the Downconverter.
+//
+// Netflow v9 sequence numbers count messages, so the Downconverter numbers
+// the messages of each exporter and observation domain itself. A
+// Downconverter is goroutine safe.
+type Downconverter struct {
+	s       *Session
+	policy  UnmappablePolicy
+	started time.Time
+
+	mut       sync.Mutex
+	sequences map[TemplateScope]uint32
+	templates map[templateKey][]TemplateFieldSpecifier // downconverted
+}
+
+// downconversion describes how the records of a template are downconverted.
+type downconversion struct {
+	fields  []TemplateFieldSpecifier // of the Netflow v9 template
+	index   []int                    // of the IPFIX field for each Netflow v9 field
+	times   []FieldType              // of the IPFIX field, Unknown unless it is a time
+	scopes  int                      // number of scope fields kept
+	dropped bool                     // the template has no fields left
+}
+
+// NewDownconverter returns a Downconverter for the messages parsed by s,
+// which holds the templates of their data records.
+func NewDownconverter(s *Session, opts ...DownconverterOption) *Downconverter {
+	d := &Downconverter{
+		s:         s,
+		started:   time.Now(),
+		sequences: make(map[TemplateScope]uint32),
+		templates: make(map[templateKey][]TemplateFieldSpecifier),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Downconvert returns the Netflow v9 message equivalent to the IPFIX message
+// msg. It fails with ErrVersion for other versions, and ErrUnknownTemplate if
+// the template of a data record is not known to the Session. Use the Marshal
+// method of the Downconverter to encode the message.
+func (d *Downconverter) Downconvert(msg Message) (Message, error) {
+	if msg.Header.Version != ipfixVersion {
+		return Message{}, ErrVersion
+	}
+	m := Message{
+		Header: MessageHeader{
+			Version:    nfv9Version,
+			SysUptime:  uint32(int64(msg.Header.ExportTime)*1000 - d.started.UnixNano()/int64(time.Millisecond)),
+			ExportTime: msg.Header.ExportTime,
+			DomainID:   msg.Header.DomainID,
+		},
+		Errors:          msg.Errors,
+		PeerCertificate: msg.PeerCertificate,
+	}
+
+	for _, tr := range msg.TemplateRecords {
+		if len(tr.FieldSpecifiers) == 0 {
+			// Netflow v9 has no template withdrawal
+			continue
+		}
+		dc, err := d.downconversion(tr.FieldSpecifiers, 0)
+		if err != nil {
+			return Message{}, err
+		}
+		if !dc.dropped {
+			tr.FieldSpecifiers = dc.fields
+			m.TemplateRecords = append(m.TemplateRecords, tr)
+		}
+	}
+	for _, otr := range msg.OptionsTemplateRecords {
+		if len(otr.FieldSpecifiers) == 0 {
+			continue
+		}
+		dc, err := d.downconversion(otr.FieldSpecifiers, int(otr.ScopeFieldCount))
+		if err != nil {
+			return Message{}, err
+		}
+		if !dc.dropped {
+			otr.ScopeFieldCount = uint16(dc.scopes)
+			otr.FieldSpecifiers = dc.fields
+			m.OptionsTemplateRecords = append(m.OptionsTemplateRecords, otr)
+		}
+	}
+
+	// The records of a message mostly share a few templates
+	dcs := make(map[templateKey]downconversion)
+	lookup := func(scope TemplateScope, tid uint16, scopeCount int) (downconversion, error) {
+		key := templateKey{scope, tid}
+		if dc, ok := dcs[key]; ok {
+			return dc, nil
+		}
+		tpl := d.s.lookupUnaliasedTemplateFieldSpecifiers(scope, tid)
+		if tpl == nil {
+			return downconversion{}, ErrUnknownTemplate
+		}
+		dc, err := d.downconversion(tpl, scopeCount)
+		if err != nil {
+			return downconversion{}, err
+		}
+		dcs[key] = dc
+		return dc, nil
+	}
+
+	for _, dr := range msg.DataRecords {
+		dc, err := lookup(dr.Scope, dr.TemplateID, 0)
+		if err != nil {
+			return Message{}, err
+		}
+		if !dc.dropped {
+			dr.Fields = dc.convert(m.Header, dr.Fields)
+			m.DataRecords = append(m.DataRecords, dr)
+		}
+	}
+	for _, odr := range msg.OptionsDataRecords {
+		dc, err := lookup(odr.Scope, odr.TemplateID, len(odr.ScopeFields))
+		if err != nil {
+			return Message{}, err
+		}
+		if !dc.dropped {
+			fields := dc.convert(m.Header, append(append([][]byte{}, odr.ScopeFields...), odr.Fields...))
+			odr.ScopeFields, odr.Fields = fields[:dc.scopes], fields[dc.scopes:]
+			m.OptionsDataRecords = append(m.OptionsDataRecords, odr)
+		}
+	}
+
+	scope := messageScope(msg)
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	for key, dc := range dcs {
+		d.templates[key] = dc.fields
+	}
+	m.Header.SequenceNumber = d.sequences[scope]
+	d.sequences[scope]++
+	return m, nil
+}
+
+// Marshal encodes a message returned by Downconvert. The templates of its
+// data records must have been downconverted before, with this or an earlier
+// message.
+func (d *Downconverter) Marshal(m Message) ([]byte, error) {
+	return m.marshal(d.lookup)
+}
+
+func (d *Downconverter) lookup(scope TemplateScope, tid uint16) []TemplateFieldSpecifier {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	return d.templates[templateKey{scope, tid}]
+}
+
+// downconversion returns the downconversion of a template. The first
+// scopeCount fields are options template scope fields.
+func (d *Downconverter) downconversion(tpl []TemplateFieldSpecifier, scopeCount int) (downconversion, error) {
+	var dc downconversion
+	for i, f := range tpl {
+		nf := TemplateFieldSpecifier{FieldID: f.FieldID, Length: f.Length}
+		t := Unknown
+		mapped := f.Length != 0xffff
+		switch {
+		case !mapped:
+		case i < scopeCount:
+			nf.FieldID, mapped = ipfixScopeFields[f.FieldID]
+			mapped = mapped && f.EnterpriseID == 0
+		case f.EnterpriseID == ciscoEnterpriseID:
+			nf.FieldID |= 0x8000
+		case f.EnterpriseID != 0:
+			mapped = false
+		default:
+			if tf, ok := ipfixTimeFields[f.FieldID]; ok {
+				nf = TemplateFieldSpecifier{FieldID: tf.nfv9, Length: 4}
+				t = tf.t
+			}
+		}
+
+		if !mapped {
+			if d.policy == FailUnmappable {
+				return downconversion{}, ErrUnmappable
+			}
+			continue
+		}
+		if i < scopeCount {
+			dc.scopes++
+		}
+		dc.fields = append(dc.fields, nf)
+		dc.index = append(dc.index, i)
+		dc.times = append(dc.times, t)
+	}
+	dc.dropped = len(dc.fields) == 0 || (scopeCount > 0 && dc.scopes == 0)
+	return dc, nil
+}
+
+// convert returns the fields of a Netflow v9 record from those of the IPFIX
+// record. Fields which need no conversion are shared.
+func (dc downconversion) convert(hdr MessageHeader, fields [][]byte) [][]byte {
+	nfields := make([][]byte, 0, len(dc.index))
+	for j, i := range dc.index {
+		if i >= len(fields) {
+			break
+		}
+		if dc.times[j] == Unknown {
+			nfields = append(nfields, fields[i])
+			continue
+		}
+		t, ok := interpretBytes(&fields[i], dc.times[j]).(time.Time)
+		if !ok || t.IsZero() {
+			nfields = append(nfields, make([]byte, 4))
+			continue
+		}
+		age := int64(hdr.ExportTime)*1000 - t.UnixNano()/int64(time.Millisecond)
+		f := make([]byte, 4)
+		binary.BigEndian.PutUint32(f, hdr.SysUptime-uint32(age))
+		nfields = append(nfields, f)
+	}
+	return nfields
+}
@@ -0,0 +1,126 @@
+package ipfix
+
+import (
+	"testing"
+)
+
+var downconvertTemplate = TemplateRecord{
+	TemplateID: 256,
+	FieldSpecifiers: []TemplateFieldSpecifier{
+		{FieldID: 8, Length: 4},                      // sourceIPv4Address
+		{FieldID: 1, Length: 8},                      // octetDeltaCount
+		{FieldID: 152, Length: 8},                    // flowStartMilliseconds
+		{FieldID: 153, Length: 8},                    // flowEndMilliseconds
+		{FieldID: 82, Length: 0xffff},                // interfaceName
+		{FieldID: 1, EnterpriseID: 12345, Length: 2}, // vendor field
+		{FieldID: 5, EnterpriseID: 9, Length: 2},     // Cisco field
+	},
+}
+
+func TestDownconvert(t *testing.T) {
+	ipfix := Message{
+		Header:          MessageHeader{Version: 10, ExportTime: 1500000000, SequenceNumber: 100, DomainID: 3},
+		TemplateRecords: []TemplateRecord{downconvertTemplate},
+		DataRecords: []DataRecord{{
+			TemplateID: 256,
+			Fields: [][]byte{
+				{192, 0, 2, 1},
+				{0, 0, 0, 0, 0, 0, 4, 0},
+				{0, 0, 1, 0x5d, 0x3e, 0xf7, 0x70, 0xf0}, // 1499999990000 ms
+				{0, 0, 1, 0x5d, 0x3e, 0xf7, 0x90, 0x30}, // 1499999998000 ms
+				[]byte("eth0"),
+				{1, 2},
+				{3, 4},
+			},
+		}},
+	}
+	bs, err := ipfix.Marshal()
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	s := NewSession()
+	msg, err := s.ParseBuffer(bs)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+
+	d := NewDownconverter(s)
+	m, err := d.Downconvert(msg)
+	if err != nil {
+		t.Fatal("Downconvert failed", err)
+	}
+	fs := m.TemplateRecords[0].FieldSpecifiers
+	if len(fs) != 5 {
+		t.Fatal("Incorrect number of fields", fs)
+	}
+	for i, id := range []uint16{8, 1, 22, 21, 0x8005} {
+		if fs[i].FieldID != id || fs[i].EnterpriseID != 0 {
+			t.Errorf("Incorrect field %d %+v", i, fs[i])
+		}
+	}
+	if m.Header.Version != 9 || m.Header.SequenceNumber != 0 || m.Header.DomainID != 3 {
+		t.Errorf("Incorrect header %+v", m.Header)
+	}
+	if len(m.DataRecords) != 1 || len(m.DataRecords[0].Fields) != 5 {
+		t.Fatal("Incorrect data records", m.DataRecords)
+	}
+
+	if _, err := d.Marshal(Message{Header: m.Header, DataRecords: m.DataRecords}); err != nil {
+		t.Error("Marshal failed", err)
+	}
+
+	// Translating the Netflow v9 message back restores the times
+	m.TemplateRecords[0].FieldSpecifiers = fs[:4]
+	m.DataRecords[0].Fields = m.DataRecords[0].Fields[:4]
+	bs, err = m.Marshal()
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	p := NewSession()
+	if m, err = p.ParseBuffer(bs); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if m.Header.Length != 2 {
+		t.Error("Incorrect record count", m.Header.Length)
+	}
+	if m, err = NewTranslator(p).Translate(m); err != nil {
+		t.Fatal("Translate failed", err)
+	}
+	for i := 0; i < 4; i++ {
+		if string(m.DataRecords[0].Fields[i]) != string(ipfix.DataRecords[0].Fields[i]) {
+			t.Errorf("Incorrect field %d %x", i, m.DataRecords[0].Fields[i])
+		}
+	}
+}
+
+func TestDownconvertUnmappable(t *testing.T) {
+	s := NewSession()
+	s.LoadTemplateRecords([]TemplateRecord{downconvertTemplate})
+	msg := Message{Header: MessageHeader{Version: 10}, TemplateRecords: []TemplateRecord{downconvertTemplate}}
+	if _, err := NewDownconverter(s, WithUnmappable(FailUnmappable)).Downconvert(msg); err != ErrUnmappable {
+		t.Error("Expected ErrUnmappable, got", err)
+	}
+	if _, err := NewDownconverter(s).Downconvert(Message{Header: MessageHeader{Version: 9}}); err != ErrVersion {
+		t.Error("Expected ErrVersion, got", err)
+	}
+
+	// Records of templates without mappable fields are dropped
+	tr := TemplateRecord{TemplateID: 257, FieldSpecifiers: []TemplateFieldSpecifier{{FieldID: 82, Length: 0xffff}}}
+	s.LoadTemplateRecords([]TemplateRecord{tr})
+	msg = Message{
+		Header:          MessageHeader{Version: 10},
+		TemplateRecords: []TemplateRecord{tr},
+		DataRecords:     []DataRecord{{TemplateID: 257, Fields: [][]byte{[]byte("eth0")}}},
+	}
+	d := NewDownconverter(s)
+	m, err := d.Downconvert(msg)
+	if err != nil {
+		t.Fatal("Downconvert failed", err)
+	}
+	if len(m.TemplateRecords) != 0 || len(m.DataRecords) != 0 {
+		t.Error("Unmappable template not dropped", m)
+	}
+	if _, err := d.Marshal(m); err != nil {
+		t.Error("Marshal failed", err)
+	}
+}
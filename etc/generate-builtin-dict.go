@@ -1,3 +1,6 @@
+//go:build ignore
+// +build ignore
+
 package main
 
 import (
@@ -7,14 +10,54 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type ipfixRecord struct {
-	Name      string `xml:"name"`
-	ElementId string `xml:"elementId"`
-	DataType  string `xml:"dataType"`
+	Name              string `xml:"name"`
+	ElementId         string `xml:"elementId"`
+	DataType          string `xml:"dataType"`
+	DataTypeSemantics string `xml:"dataTypeSemantics"`
+	Units             string `xml:"units"`
+	Range             string `xml:"range"`
+	Status            string `xml:"status"`
+}
+
+// semanticsConstants are the names of the ipfix.Semantics constants.
+var semanticsConstants = map[string]string{
+	"quantity":     "Quantity",
+	"totalCounter": "TotalCounter",
+	"deltaCounter": "DeltaCounter",
+	"identifier":   "Identifier",
+	"flags":        "Flags",
+	"list":         "List",
+	"snmpCounter":  "SNMPCounter",
+	"snmpGauge":    "SNMPGauge",
+}
+
+// optionalFields returns the fields of the DictionaryEntry literal for the
+// attributes which are only defined for some elements.
+func optionalFields(r ipfixRecord) string {
+	var s string
+	if c, ok := semanticsConstants[strings.TrimSpace(r.DataTypeSemantics)]; ok {
+		s += ", Semantics: " + c
+	}
+	if units := strings.TrimSpace(r.Units); units != "" && units != "none" {
+		s += fmt.Sprintf(", Units: %q", units)
+	}
+	if bounds := strings.SplitN(strings.TrimSpace(r.Range), "-", 2); len(bounds) == 2 {
+		min, err1 := strconv.ParseUint(strings.TrimSpace(bounds[0]), 0, 64)
+		max, err2 := strconv.ParseUint(strings.TrimSpace(bounds[1]), 0, 64)
+		if err1 == nil && err2 == nil {
+			s += fmt.Sprintf(", Range: ValueRange{%d, %d}", min, max)
+		}
+	}
+	if status := strings.TrimSpace(r.Status); status != "" && status != "current" {
+		s += fmt.Sprintf(", Status: %q", status)
+	}
+	return s
 }
 
 type ipfixRegistry struct {
@@ -39,18 +82,19 @@ func createIpfixRegistry(records []ipfixRecord) {
 
 	dictFile.WriteString("package ipfix\n\n")
 	dictFile.WriteString("// Autogenerated " + time.Now().Format(time.UnixDate) + "\n")
-	dictFile.WriteString("var builtinDictionary = fieldDictionary{\n")
+	dictFile.WriteString("var builtinIpfixDictionary = fieldDictionary{\n")
 	for _, r := range records {
 
 		if r.Name == "" || r.DataType == "" || r.ElementId == "" {
 			continue
 		}
 
-		dictFile.WriteString(fmt.Sprintf("\tdictionaryKey{0, %3s}: DictionaryEntry{FieldID: %3s, Name: \"%s\", Type: FieldTypes[\"%s\"]},\n",
+		dictFile.WriteString(fmt.Sprintf("\tdictionaryKey{0, %3s}: DictionaryEntry{FieldID: %3s, Name: \"%s\", Type: FieldTypes[\"%s\"]%s},\n",
 			strings.TrimSpace(r.ElementId),
 			strings.TrimSpace(r.ElementId),
 			strings.TrimSpace(r.Name),
-			strings.TrimSpace(r.DataType)))
+			strings.TrimSpace(r.DataType),
+			optionalFields(r)))
 	}
 	dictFile.WriteString("}\n")
 }
@@ -1,3 +1,6 @@
+//go:build ignore
+// +build ignore
+
 package main
 
 import (
@@ -70,4 +73,4 @@ func decodeV9Records() (nfrecs []nfv9Record) {
 func main() {
 	recs := decodeV9Records()
 	createV9Registry(recs)
-}
\ No newline at end of file
+}
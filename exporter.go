@@ -0,0 +1,386 @@
+package ipfix
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// An ExporterOption configures an Exporter, see NewExporter.
+type ExporterOption func(*Exporter)
+
+// WithExportVersion sets the version of the messages written, 0x0a for IPFIX
+// (the default) or 0x09 for Netflow v9.
+func WithExportVersion(v uint16) ExporterOption {
+	return func(e *Exporter) {
+		e.version = v
+	}
+}
+
+// WithMTU sets the maximum length of the messages written. Records are packed
+// into messages until the next one would exceed it. The default of 1400
+// leaves room for the IP and UDP headers on an Ethernet link.
+func WithMTU(n int) ExporterOption {
+	return func(e *Exporter) {
+		e.mtu = n
+	}
+}
+
+// WithTemplateRefresh sets the interval after which the templates of an
+// observation domain are sent again. RFC 7011 requires this when exporting
+// over UDP, and suggests 600 seconds. The default of zero sends every
+// template once, which is correct for TCP and TLS.
+func WithTemplateRefresh(d time.Duration) ExporterOption {
+	return func(e *Exporter) {
+		e.refresh = d
+	}
+}
+
+// An Exporter packs data records into IPFIX or Netflow v9 messages and writes
+// them to an io.Writer, such as a net.Conn. Every message is written with a
+// single call to Write, so a UDP socket sends one message per datagram.
+//
+// Templates are kept per observation domain. They are sent before the first
+// record using them and, if WithTemplateRefresh is set, periodically after
+// that. Sequence numbers are kept per observation domain as well, counting
+// the data records sent for IPFIX and the messages sent for NFv9.
+//
+// Records are buffered until a message is full, call Flush to send a partly
+// filled message. An Exporter is goroutine safe.
+type Exporter struct {
+	w       io.Writer
+	version uint16
+	mtu     int
+	refresh time.Duration
+	now     func() time.Time
+	started time.Time
+
+	mut     sync.Mutex
+	domains map[uint32]*exportDomain
+}
+
+// exportDomain holds the templates and sequence number of an observation
+// domain, the message being filled and the filled messages not yet written.
+type exportDomain struct {
+	id          uint32
+	templates   map[uint16]TemplateRecord
+	options     map[uint16]OptionsTemplateRecord
+	order       []uint16 // template IDs in the order they were added
+	unsent      map[uint16]bool
+	lastRefresh time.Time
+	sequence    uint32    // of the message being filled
+	cur         Message   // templates and records of the message being filled
+	length      int       // encoded length of cur
+	filled      []Message // messages waiting to be written
+}
+
+// NewExporter returns an Exporter writing to w.
+func NewExporter(w io.Writer, opts ...ExporterOption) *Exporter {
+	e := &Exporter{
+		w:       w,
+		version: ipfixVersion,
+		mtu:     1400,
+		now:     time.Now,
+		domains: make(map[uint32]*exportDomain),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.started = e.now()
+	return e
+}
+
+func (e *Exporter) domain(id uint32) *exportDomain {
+	d, ok := e.domains[id]
+	if !ok {
+		d = &exportDomain{
+			id:        id,
+			templates: make(map[uint16]TemplateRecord),
+			options:   make(map[uint16]OptionsTemplateRecord),
+			unsent:    make(map[uint16]bool),
+		}
+		e.startMessage(d)
+		e.domains[id] = d
+	}
+	return d
+}
+
+// AddTemplate adds or replaces a template of an observation domain. The
+// template is sent with the next message of the domain. Template IDs must be
+// 256 or above, and unique among the templates and options templates of the
+// domain.
+func (e *Exporter) AddTemplate(domainID uint32, tr TemplateRecord) error {
+	if tr.TemplateID < 256 || len(tr.FieldSpecifiers) == 0 {
+		return ErrProtocol
+	}
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	d := e.domain(domainID)
+	if err := e.flushReplaced(d, tr.TemplateID); err != nil {
+		return err
+	}
+	delete(d.options, tr.TemplateID)
+	d.templates[tr.TemplateID] = tr
+	return nil
+}
+
+// AddOptionsTemplate works like AddTemplate for options templates.
+func (e *Exporter) AddOptionsTemplate(domainID uint32, otr OptionsTemplateRecord) error {
+	if otr.TemplateID < 256 || otr.ScopeFieldCount == 0 || int(otr.ScopeFieldCount) > len(otr.FieldSpecifiers) {
+		return ErrProtocol
+	}
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	d := e.domain(domainID)
+	if err := e.flushReplaced(d, otr.TemplateID); err != nil {
+		return err
+	}
+	delete(d.templates, otr.TemplateID)
+	d.options[otr.TemplateID] = otr
+	return nil
+}
+
+// flushReplaced prepares the domain for a new template with the given ID.
+// Buffered templates and records are sent first, since they may use the old
+// template.
+func (e *Exporter) flushReplaced(d *exportDomain, tid uint16) error {
+	if !d.empty() || len(d.filled) > 0 {
+		if err := e.flushDomain(d); err != nil {
+			return err
+		}
+	}
+	if _, ok := d.templates[tid]; !ok {
+		if _, ok := d.options[tid]; !ok {
+			d.order = append(d.order, tid)
+		}
+	}
+	d.unsent[tid] = true
+	return nil
+}
+
+// lookup returns the field specifiers of a template of the domain.
+func (d *exportDomain) lookup(_ TemplateScope, tid uint16) []TemplateFieldSpecifier {
+	if tr, ok := d.templates[tid]; ok {
+		return tr.FieldSpecifiers
+	}
+	return d.options[tid].FieldSpecifiers
+}
+
+// Add buffers data records of an observation domain, and writes the messages
+// which have been filled. The TemplateID of each record must refer to a
+// template added to the domain. Records of an options template hold the
+// scope fields followed by the option fields.
+func (e *Exporter) Add(domainID uint32, recs ...DataRecord) error {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	d := e.domain(domainID)
+	e.checkRefresh(d)
+	if err := e.addUnsent(d); err != nil {
+		return err
+	}
+
+	for _, dr := range recs {
+		tpl := d.lookup(TemplateScope{}, dr.TemplateID)
+		if tpl == nil {
+			return ErrUnknownTemplate
+		}
+		n, err := recordLength(tpl, dr)
+		if err != nil {
+			return err
+		}
+		dr.Scope = TemplateScope{}
+		if err := e.addRecord(d, dr, n); err != nil {
+			return err
+		}
+	}
+	return e.send(d)
+}
+
+// startMessage starts filling the next message of the domain.
+func (e *Exporter) startMessage(d *exportDomain) {
+	d.cur = Message{Header: MessageHeader{Version: e.version, DomainID: d.id, SequenceNumber: d.sequence}}
+	d.length, _, _, _ = d.cur.calculateMarshalledLength(nil)
+}
+
+// endMessage moves the message being filled to the messages waiting to be
+// written, and starts the next one.
+func (e *Exporter) endMessage(d *exportDomain) {
+	d.filled = append(d.filled, d.cur)
+	if e.version == nfv9Version {
+		d.sequence++
+	} else {
+		d.sequence += uint32(len(d.cur.DataRecords))
+	}
+	e.startMessage(d)
+}
+
+// empty returns true if nothing has been added to the message being filled.
+func (d *exportDomain) empty() bool {
+	return len(d.cur.TemplateRecords)+len(d.cur.OptionsTemplateRecords)+len(d.cur.DataRecords) == 0
+}
+
+// withTemplate returns the message being filled with a template of the
+// domain added, and the length the template adds to it.
+func (d *exportDomain) withTemplate(tid uint16) (Message, int) {
+	m := d.cur
+	m.DataRecords = nil
+	before, _, _, _ := m.calculateMarshalledLength(nil)
+	if tr, ok := d.templates[tid]; ok {
+		m.TemplateRecords = append(m.TemplateRecords[:len(m.TemplateRecords):len(m.TemplateRecords)], tr)
+	} else {
+		otrs := m.OptionsTemplateRecords[:len(m.OptionsTemplateRecords):len(m.OptionsTemplateRecords)]
+		m.OptionsTemplateRecords = append(otrs, d.options[tid])
+	}
+	after, _, _, _ := m.calculateMarshalledLength(nil)
+	m.DataRecords = d.cur.DataRecords
+	return m, after - before
+}
+
+// addTemplate adds a template to the message being filled, or to the next
+// one if it does not fit within the MTU.
+func (e *Exporter) addTemplate(d *exportDomain, tid uint16) error {
+	m, n := d.withTemplate(tid)
+	if d.length+n > e.mtu {
+		if d.empty() {
+			return ErrFieldOverflow
+		}
+		e.endMessage(d)
+		if m, n = d.withTemplate(tid); d.length+n > e.mtu {
+			return ErrFieldOverflow
+		}
+	}
+	d.cur = m
+	d.length += n
+	return nil
+}
+
+// addRecord adds a data record of length n to the message being filled, or
+// to the next one if it does not fit within the MTU.
+func (e *Exporter) addRecord(d *exportDomain, dr DataRecord, n int) error {
+	length := n
+	if last := len(d.cur.DataRecords) - 1; last < 0 || d.cur.DataRecords[last].TemplateID != dr.TemplateID {
+		length += setHeaderLength
+	}
+	if d.length+length > e.mtu {
+		if d.empty() {
+			return ErrFieldOverflow
+		}
+		e.endMessage(d)
+		return e.addRecord(d, dr, n)
+	}
+	d.cur.DataRecords = append(d.cur.DataRecords, dr)
+	d.length += length
+	return nil
+}
+
+// recordLength returns the encoded length of a data record. Fields must have
+// the length given by the template, unless it is variable.
+func recordLength(tpl []TemplateFieldSpecifier, dr DataRecord) (int, error) {
+	if len(dr.Fields) > len(tpl) {
+		return 0, ErrTooManyTemplates
+	} else if len(dr.Fields) < len(tpl) {
+		return 0, ErrProtocol
+	}
+	var n int
+	for i, field := range dr.Fields {
+		switch {
+		case tpl[i].Length != 0xffff:
+			if len(field) != int(tpl[i].Length) {
+				return 0, ErrFieldOverflow
+			}
+			n += len(field)
+		case len(field) > 0xffff:
+			return 0, ErrFieldOverflow
+		case len(field) < 0xff:
+			n += 1 + len(field)
+		default:
+			n += 3 + len(field)
+		}
+	}
+	return n, nil
+}
+
+// checkRefresh marks all templates of the domain for sending if the refresh
+// interval has passed.
+func (e *Exporter) checkRefresh(d *exportDomain) {
+	if e.refresh <= 0 || d.lastRefresh.IsZero() || e.now().Sub(d.lastRefresh) < e.refresh {
+		return
+	}
+	for _, tid := range d.order {
+		d.unsent[tid] = true
+	}
+}
+
+// addUnsent adds the unsent templates of the domain to the messages being
+// filled, spreading them over several messages if they do not fit in one.
+func (e *Exporter) addUnsent(d *exportDomain) error {
+	if len(d.unsent) == 0 {
+		return nil
+	}
+	if len(d.unsent) == len(d.order) {
+		d.lastRefresh = e.now()
+	}
+	for _, tid := range d.order {
+		if !d.unsent[tid] {
+			continue
+		}
+		if err := e.addTemplate(d, tid); err != nil {
+			return err
+		}
+		delete(d.unsent, tid)
+	}
+	return nil
+}
+
+// send writes the messages of the domain which have been filled. A message
+// which can not be written is kept, and written again by the next call.
+func (e *Exporter) send(d *exportDomain) error {
+	for len(d.filled) > 0 {
+		m := d.filled[0]
+		now := e.now()
+		m.Header.ExportTime = uint32(now.Unix())
+		if e.version == nfv9Version {
+			m.Header.SysUptime = uint32(now.Sub(e.started) / time.Millisecond)
+		}
+
+		bs, err := m.marshal(d.lookup)
+		if err != nil {
+			return err
+		}
+		if _, err := e.w.Write(bs); err != nil {
+			return err
+		}
+		d.filled = d.filled[1:]
+	}
+	d.filled = nil
+	return nil
+}
+
+// flushDomain writes the unsent templates and buffered records of the
+// domain.
+func (e *Exporter) flushDomain(d *exportDomain) error {
+	if err := e.addUnsent(d); err != nil {
+		return err
+	}
+	if !d.empty() {
+		e.endMessage(d)
+	}
+	return e.send(d)
+}
+
+// Flush writes the unsent templates and buffered records of all observation
+// domains.
+func (e *Exporter) Flush() error {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	for _, d := range e.domains {
+		e.checkRefresh(d)
+		if d.empty() && len(d.filled) == 0 && len(d.unsent) == 0 {
+			continue
+		}
+		if err := e.flushDomain(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
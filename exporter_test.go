@@ -0,0 +1,200 @@
+package ipfix
+
+import (
+	"testing"
+	"time"
+)
+
+// packetWriter keeps every write as a separate packet.
+type packetWriter struct {
+	packets [][]byte
+}
+
+func (w *packetWriter) Write(bs []byte) (int, error) {
+	w.packets = append(w.packets, append([]byte(nil), bs...))
+	return len(bs), nil
+}
+
+var exportTemplate = TemplateRecord{
+	TemplateID: 256,
+	FieldSpecifiers: []TemplateFieldSpecifier{
+		{FieldID: 8, Length: 4},
+		{FieldID: 12, Length: 4},
+		{FieldID: 2, Length: 4},
+	},
+}
+
+func exportRecord(i byte) DataRecord {
+	return DataRecord{
+		TemplateID: 256,
+		Fields:     [][]byte{{192, 0, 2, i}, {198, 51, 100, i}, {0, 0, 0, i}},
+	}
+}
+
+func TestExporterIPFIX(t *testing.T) {
+	var w packetWriter
+	e := NewExporter(&w, WithMTU(100))
+	if err := e.AddTemplate(1, exportTemplate); err != nil {
+		t.Fatal("AddTemplate failed", err)
+	}
+	for i := 0; i < 12; i++ {
+		if err := e.Add(1, exportRecord(byte(i))); err != nil {
+			t.Fatal("Add failed", err)
+		}
+	}
+	if len(w.packets) != 2 {
+		t.Fatal("Incorrect number of messages before Flush", len(w.packets))
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal("Flush failed", err)
+	}
+
+	p := NewSession()
+	var i byte
+	for n, want := range []struct {
+		templates, records int
+		sequence           uint32
+	}{{1, 5, 0}, {0, 6, 5}, {0, 1, 11}} {
+		if len(w.packets[n]) > 100 {
+			t.Error("Message exceeds MTU", len(w.packets[n]))
+		}
+		msg, err := p.ParseBuffer(w.packets[n])
+		if err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if len(msg.TemplateRecords) != want.templates || len(msg.DataRecords) != want.records {
+			t.Errorf("Message %d has %d templates and %d records", n, len(msg.TemplateRecords), len(msg.DataRecords))
+		}
+		if msg.Header.SequenceNumber != want.sequence || msg.Header.DomainID != 1 {
+			t.Errorf("Incorrect header %+v", msg.Header)
+		}
+		for _, dr := range msg.DataRecords {
+			if dr.Fields[2][3] != i {
+				t.Error("Incorrect record", dr.Fields)
+			}
+			i++
+		}
+	}
+	if st := p.SequenceStats()[TemplateScope{DomainID: 1}]; st.Lost != 0 || st.Messages != 3 {
+		t.Errorf("Incorrect sequence stats %+v", st)
+	}
+}
+
+func TestExporterTemplateSplit(t *testing.T) {
+	var w packetWriter
+	e := NewExporter(&w, WithMTU(100))
+	for tid := uint16(256); tid < 261; tid++ {
+		tr := TemplateRecord{TemplateID: tid}
+		for f := uint16(1); f <= 12; f++ {
+			tr.FieldSpecifiers = append(tr.FieldSpecifiers, TemplateFieldSpecifier{FieldID: f, Length: 1})
+		}
+		if err := e.AddTemplate(1, tr); err != nil {
+			t.Fatal("AddTemplate failed", err)
+		}
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal("Flush failed", err)
+	}
+
+	if len(w.packets) < 3 {
+		t.Fatal("Incorrect number of messages", len(w.packets))
+	}
+	p := NewSession()
+	var templates int
+	for _, bs := range w.packets {
+		if len(bs) > 100 {
+			t.Error("Message exceeds MTU", len(bs))
+		}
+		msg, err := p.ParseBuffer(bs)
+		if err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		templates += len(msg.TemplateRecords)
+	}
+	if templates != 5 {
+		t.Error("Incorrect number of templates", templates)
+	}
+}
+
+func TestExporterNFv9(t *testing.T) {
+	var w packetWriter
+	e := NewExporter(&w, WithExportVersion(9))
+	if err := e.AddTemplate(7, exportTemplate); err != nil {
+		t.Fatal("AddTemplate failed", err)
+	}
+	for n := 0; n < 3; n++ {
+		if err := e.Add(7, exportRecord(1), exportRecord(2)); err != nil {
+			t.Fatal("Add failed", err)
+		}
+		if err := e.Flush(); err != nil {
+			t.Fatal("Flush failed", err)
+		}
+	}
+
+	p := NewSession()
+	for n, bs := range w.packets {
+		msg, err := p.ParseBuffer(bs)
+		if err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if msg.Header.Version != 9 || msg.Header.SequenceNumber != uint32(n) || msg.Header.DomainID != 7 {
+			t.Errorf("Incorrect header %+v", msg.Header)
+		}
+		if int(msg.Header.Length) != len(msg.TemplateRecords)+len(msg.DataRecords) || len(msg.DataRecords) != 2 {
+			t.Errorf("Incorrect record count %d", msg.Header.Length)
+		}
+	}
+}
+
+func TestExporterTemplateRefresh(t *testing.T) {
+	var w packetWriter
+	now := time.Unix(1500000000, 0)
+	e := NewExporter(&w, WithTemplateRefresh(time.Minute))
+	e.now = func() time.Time { return now }
+	if err := e.AddTemplate(1, exportTemplate); err != nil {
+		t.Fatal("AddTemplate failed", err)
+	}
+
+	for _, step := range []time.Duration{0, 30 * time.Second, 31 * time.Second, time.Second} {
+		now = now.Add(step)
+		if err := e.Add(1, exportRecord(1)); err != nil {
+			t.Fatal("Add failed", err)
+		}
+		if err := e.Flush(); err != nil {
+			t.Fatal("Flush failed", err)
+		}
+	}
+
+	p := NewSession()
+	for n, want := range []int{1, 0, 1, 0} {
+		msg, err := p.ParseBuffer(w.packets[n])
+		if err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if len(msg.TemplateRecords) != want {
+			t.Errorf("Message %d has %d templates", n, len(msg.TemplateRecords))
+		}
+	}
+}
+
+func TestExporterErrors(t *testing.T) {
+	var w packetWriter
+	e := NewExporter(&w)
+	if err := e.AddTemplate(1, TemplateRecord{TemplateID: 2}); err != ErrProtocol {
+		t.Error("Expected ErrProtocol, got", err)
+	}
+	if err := e.Add(1, exportRecord(1)); err != ErrUnknownTemplate {
+		t.Error("Expected ErrUnknownTemplate, got", err)
+	}
+	if err := e.AddTemplate(1, exportTemplate); err != nil {
+		t.Fatal("AddTemplate failed", err)
+	}
+	dr := exportRecord(1)
+	dr.Fields[0] = []byte{1}
+	if err := e.Add(1, dr); err != ErrFieldOverflow {
+		t.Error("Expected ErrFieldOverflow, got", err)
+	}
+	if len(w.packets) != 0 {
+		t.Error("Incorrect number of messages", len(w.packets))
+	}
+}
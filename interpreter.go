@@ -8,14 +8,43 @@ import (
 	"math"
 	"net"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 )
 
 // Interpreter provides translation between the raw bytes of a DataRecord
-// and the actual values as specified by the corresponding template.
+// and the actual values as specified by the corresponding template. Its
+// methods may be called concurrently.
 type Interpreter struct {
-	dictionary fieldDictionary
-	session    *Session
+	session   *Session
+	unixTimes bool
+	symbolic  bool
+
+	mut        sync.RWMutex
+	dictionary *Dictionary
+}
+
+// An InterpreterOption configures an Interpreter, see NewInterpreter.
+type InterpreterOption func(*Interpreter)
+
+// WithUnixTimes makes the Interpreter decode dateTimeMicroseconds and
+// dateTimeNanoseconds fields as 64 bit counts since the Unix epoch, rather
+// than as the NTP timestamps of RFC 7011. Some exporters send them this
+// way, and it was the behaviour of earlier versions of this package.
+func WithUnixTimes() InterpreterOption {
+	return func(i *Interpreter) {
+		i.unixTimes = true
+	}
+}
+
+// WithDictionary makes the Interpreter use the given dictionary rather than
+// the builtin one for the version of the Session. Entries added to the
+// Interpreter afterwards do not change d.
+func WithDictionary(d *Dictionary) InterpreterOption {
+	return func(i *Interpreter) {
+		i.dictionary = d
+	}
 }
 
 // FieldType is the IPFIX type of an Information Element ("Field").
@@ -80,9 +109,9 @@ func (t FieldType) minLength() int {
 	switch t {
 	case Uint8, Int8, Boolean, Uint16, Int16, Uint24, Uint32, Int32, Uint64, Int64:
 		return 1 // all integers can be reduced-size encoded
-	case Float32, DateTimeSeconds:
-		return 4
-	case Float64, DateTimeMilliseconds, DateTimeMicroseconds, DateTimeNanoseconds:
+	case Float32, Float64, DateTimeSeconds:
+		return 4 // float64 can be sent as float32
+	case DateTimeMilliseconds, DateTimeMicroseconds, DateTimeNanoseconds:
 		return 8
 	case MacAddress:
 		return 6
@@ -97,13 +126,78 @@ func (t FieldType) minLength() int {
 	}
 }
 
+// Semantics is the IANA data type semantics of an Information Element,
+// which tells how its values are to be understood (RFC 7012 section 3.2).
+type Semantics int
+
+// The data type semantics, numbered as in the IANA registry.
+const (
+	DefaultSemantics Semantics = iota
+	Quantity
+	TotalCounter
+	DeltaCounter
+	Identifier
+	Flags
+	List
+	SNMPCounter
+	SNMPGauge
+)
+
+// SemanticsNames maps the IANA names of data type semantics into their
+// corresponding Semantics value.
+var SemanticsNames = map[string]Semantics{
+	"default":      DefaultSemantics,
+	"quantity":     Quantity,
+	"totalCounter": TotalCounter,
+	"deltaCounter": DeltaCounter,
+	"identifier":   Identifier,
+	"flags":        Flags,
+	"list":         List,
+	"snmpCounter":  SNMPCounter,
+	"snmpGauge":    SNMPGauge,
+}
+
+func (s Semantics) String() string {
+	for name, v := range SemanticsNames {
+		if v == s {
+			return name
+		}
+	}
+	return "Semantics(" + strconv.Itoa(int(s)) + ")"
+}
+
+func (s *Semantics) UnmarshalText(bs []byte) error {
+	*s = SemanticsNames[string(bs)]
+	return nil
+}
+
+// A ValueRange is the range of valid values of an Information Element, both
+// ends included.
+type ValueRange struct {
+	Min, Max uint64
+}
+
 // DictionaryEntry provides a mapping between an (Enterprise, Field) pair and
-// a Name and Type.
+// a Name and Type. The other fields are those of the IANA registry, and are
+// left empty when the registry does not define them: Units as in "octets" or
+// "packets", Range for the elements with a range of valid values, and Status
+// for elements which are "deprecated" or "obsolete". Symbols names the values
+// of enumerated and bitfield elements, see WithSymbolicValues.
 type DictionaryEntry struct {
 	Name         string
 	FieldID      uint16
 	EnterpriseID uint32
 	Type         FieldType
+	Semantics    Semantics
+	Units        string
+	Range        ValueRange
+	Status       string
+	Symbols      *Symbols
+}
+
+// Deprecated reports whether the element should no longer be used.
+func (e DictionaryEntry) Deprecated() bool {
+	return e.Status == "deprecated" || e.Status == "obsolete"
 }
 
 func (f *FieldType) UnmarshalText(bs []byte) error {
@@ -122,13 +216,17 @@ type fieldDictionary map[dictionaryKey]DictionaryEntry
 // converted to the appropriate type.  If this is not possible (because the
 // name and type of the field is unknown at the time of interpretation), Name
 // will be the empty string, Value will be a nil interface and RawValue will
-// contain the original bytes.
+// contain the original bytes. Semantics, Units and Deprecated are those of
+// the DictionaryEntry of the field.
 type InterpretedField struct {
 	Name         string
 	EnterpriseID uint32
 	FieldID      uint16
 	Value        interface{}
 	RawValue     []byte
+	Semantics    Semantics
+	Units        string
+	Deprecated   bool
 }
 
 // An InterpretedTemplateFieldSpecifier is a template specifier with the field
@@ -141,23 +239,31 @@ type InterpretedTemplateFieldSpecifier struct {
 // NewInterpreter craets a new Interpreter based on the specified Session.
 // It will attempt to use the appropriate dictionary (IPFIX or NFv9) based
 // on the Session's most-recently parsed message.
-func NewInterpreter(s *Session) *Interpreter {
+func NewInterpreter(s *Session, opts ...InterpreterOption) *Interpreter {
 	if s.Version() == 0x09 {
-		return &Interpreter{builtinNetflowV9Dictionary, s}
+		return newInterpreter(netflowV9Dictionary, s, opts)
 	}
-	return &Interpreter{builtinIpfixDictionary, s}
+	return newInterpreter(ipfixDictionary, s, opts)
 }
 
-func NewInterpreterVersion(s *Session, v uint16) (*Interpreter, error) {
+func NewInterpreterVersion(s *Session, v uint16, opts ...InterpreterOption) (*Interpreter, error) {
 	if v == 0x09 {
-		return &Interpreter{builtinNetflowV9Dictionary, s}, nil
-	} else if v == 0x0a {
-		return &Interpreter{builtinIpfixDictionary, s}, nil
+		return newInterpreter(netflowV9Dictionary, s, opts), nil
+	} else if v == 0x0a || isFixedFormat(v) {
+		return newInterpreter(ipfixDictionary, s, opts), nil
 	} else {
 		return nil, errors.New("Invalid version")
 	}
 }
 
+func newInterpreter(dictionary *Dictionary, s *Session, opts []InterpreterOption) *Interpreter {
+	i := &Interpreter{dictionary: dictionary, session: s}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
 // Interpret a raw DataRecord into a list of InterpretedFields.
 func (i *Interpreter) Interpret(rec DataRecord) []InterpretedField {
 	return i.InterpretInto(rec, nil)
@@ -166,11 +272,12 @@ func (i *Interpreter) Interpret(rec DataRecord) []InterpretedField {
 // InterpretInto interprets a raw DataRecord into an existing slice of
 // InterpretedFields. If the slice is not long enough it will be reallocated.
 func (i *Interpreter) InterpretInto(rec DataRecord, fieldList []InterpretedField) []InterpretedField {
-	tpl := i.session.lookupTemplateFieldSpecifiers(rec.TemplateID)
+	tpl := i.session.lookupUnaliasedTemplateFieldSpecifiers(rec.Scope, rec.TemplateID)
 	if tpl == nil {
 		return nil
 	}
 
+	dict := i.Dictionary()
 	if len(fieldList) < len(tpl) {
 		fieldList = make([]InterpretedField, len(tpl))
 	} else {
@@ -181,9 +288,16 @@ func (i *Interpreter) InterpretInto(rec DataRecord, fieldList []InterpretedField
 		fieldList[j].FieldID = field.FieldID
 		fieldList[j].EnterpriseID = field.EnterpriseID
 
-		if entry, ok := i.dictionary[dictionaryKey{field.EnterpriseID, field.FieldID}]; ok {
+		entry, ok := dict.lookup(dictionaryKey{field.EnterpriseID, field.FieldID})
+		fieldList[j].Semantics = entry.Semantics
+		fieldList[j].Units = entry.Units
+		fieldList[j].Deprecated = entry.Deprecated()
+		if ok {
 			fieldList[j].Name = entry.Name
-			fieldList[j].Value = interpretBytes(&rec.Fields[j], entry.Type)
+			fieldList[j].Value = i.interpretBytes(&rec.Fields[j], entry.Type)
+			if entry.Symbols != nil && i.symbolic {
+				fieldList[j].Value = symbolicValue(entry.Symbols, fieldList[j].Value)
+			}
 		} else {
 			fieldList[j].RawValue = rec.Fields[j]
 		}
@@ -198,9 +312,10 @@ func (i *Interpreter) InterpretTemplate(rec TemplateRecord) []InterpretedTemplat
 
 	fieldList := make([]InterpretedTemplateFieldSpecifier, len(rec.FieldSpecifiers))
 
+	dict := i.Dictionary()
 	for j, field := range rec.FieldSpecifiers {
 		fieldList[j].TemplateFieldSpecifier = field
-		if entry, ok := i.dictionary[dictionaryKey{field.EnterpriseID, field.FieldID}]; ok {
+		if entry, ok := dict.lookup(dictionaryKey{field.EnterpriseID, field.FieldID}); ok {
 			fieldList[j].Name = entry.Name
 		}
 	}
@@ -209,13 +324,38 @@ func (i *Interpreter) InterpretTemplate(rec TemplateRecord) []InterpretedTemplat
 }
 
 // AddDictionaryEntry adds a DictionaryEntry (containing a vendor field) to
-// the dictionary used by Interpret.
+// the dictionary used by Interpret. The entry is added to a copy of the
+// dictionary, so that other Interpreters are not affected.
 func (i *Interpreter) AddDictionaryEntry(e DictionaryEntry) {
-	i.dictionary[dictionaryKey{e.EnterpriseID, e.FieldID}] = e
+	i.mut.Lock()
+	i.dictionary = i.dictionary.With(e)
+	i.mut.Unlock()
+}
+
+// Dictionary returns the dictionary used by Interpret, including the entries
+// added so far.
+func (i *Interpreter) Dictionary() *Dictionary {
+	i.mut.RLock()
+	defer i.mut.RUnlock()
+	return i.dictionary
 }
 
 var md5HashSalt = []byte(os.Getenv("IPFIX_IP_HASH"))
 
+// interpretBytes interprets a field, as configured by the options of the
+// Interpreter.
+func (i *Interpreter) interpretBytes(bs *[]byte, t FieldType) interface{} {
+	if i.unixTimes && len(*bs) == 8 {
+		switch t {
+		case DateTimeMicroseconds:
+			return time.Unix(0, 0).Add(time.Duration(binary.BigEndian.Uint64(*bs)) * time.Microsecond)
+		case DateTimeNanoseconds:
+			return time.Unix(0, 0).Add(time.Duration(binary.BigEndian.Uint64(*bs)))
+		}
+	}
+	return interpretBytes(bs, t)
+}
+
 func interpretBytes(bs *[]byte, t FieldType) interface{} {
 	if len(*bs) < t.minLength() {
 		// Field is too short (corrupt) - return it uninterpreted.
@@ -242,17 +382,23 @@ func interpretBytes(bs *[]byte, t FieldType) interface{} {
 	case Uint64:
 		return uint64(number(*bs))
 	case Int8:
-		return int8(number(*bs))
+		return int8(signedNumber(*bs))
 	case Int16:
-		return int16(number(*bs))
+		return int16(signedNumber(*bs))
 	case Int32:
-		return int32(number(*bs))
+		return int32(signedNumber(*bs))
 	case Int64:
-		return int64(number(*bs))
+		return signedNumber(*bs)
 	case Float32:
 		return math.Float32frombits(binary.BigEndian.Uint32(*bs))
 	case Float64:
-		return math.Float64frombits(binary.BigEndian.Uint64(*bs))
+		switch len(*bs) {
+		case 4:
+			// Reduced-size encoding as float32 (RFC 7011 section 6.2)
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(*bs)))
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(*bs))
+		}
 	case Boolean:
 		return (*bs)[0] == 1
 	case Unknown, MacAddress, OctetArray:
@@ -265,11 +411,9 @@ func interpretBytes(bs *[]byte, t FieldType) interface{} {
 		unixTimeMs := int64(binary.BigEndian.Uint64(*bs))
 		return time.Unix(0, 0).Add(time.Duration(unixTimeMs) * time.Millisecond)
 	case DateTimeMicroseconds:
-		unixTimeUs := int64(binary.BigEndian.Uint64(*bs))
-		return time.Unix(0, 0).Add(time.Duration(unixTimeUs) * time.Microsecond)
+		return ntpTime(binary.BigEndian.Uint64(*bs), time.Microsecond)
 	case DateTimeNanoseconds:
-		unixTimeNs := int64(binary.BigEndian.Uint64(*bs))
-		return time.Unix(0, 0).Add(time.Duration(unixTimeNs))
+		return ntpTime(binary.BigEndian.Uint64(*bs), time.Nanosecond)
 	case VarInt:
 		return uint64(number(*bs))
 	}
@@ -293,6 +437,16 @@ func number(bs []byte) uint64 {
 	}
 }
 
+// signedNumber decodes a signed integer of any length up to 8 bytes,
+// extending the sign of reduced-size encoded values (RFC 7011 section 6.2).
+func signedNumber(bs []byte) int64 {
+	if len(bs) == 0 || len(bs) > 8 {
+		return 0
+	}
+	shift := uint(64 - 8*len(bs))
+	return int64(number(bs)<<shift) >> shift
+}
+
 func bigEndianVarint(v []byte) uint64 {
 	if len(v) > 8 {
 		return 0
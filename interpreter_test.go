@@ -6,6 +6,7 @@ import (
 	"net"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestInterpretUint(t *testing.T) {
@@ -60,6 +61,172 @@ func TestInterpretInt(t *testing.T) {
 	}
 }
 
+func TestInterpretReducedSize(t *testing.T) {
+	bs := []byte{0xff, 0xfe}
+	v := interpretBytes(&bs, Int64)
+	if v != int64(-2) {
+		t.Errorf("%d != %d", v, -2)
+	}
+
+	bs = []byte{0x80, 0, 0}
+	v = interpretBytes(&bs, Int32)
+	if v != int32(-0x800000) {
+		t.Errorf("%d != %d", v, -0x800000)
+	}
+
+	bs = []byte{0x7f, 0xff}
+	v = interpretBytes(&bs, Int32)
+	if v != int32(0x7fff) {
+		t.Errorf("%d != %d", v, 0x7fff)
+	}
+
+	bs = []byte{0xc0, 0x20, 0, 0} // float32 -2.5
+	v = interpretBytes(&bs, Float64)
+	if v != float64(-2.5) {
+		t.Errorf("%v != %v", v, -2.5)
+	}
+
+	// Values encoded by the RecordBuilder decode to the same value
+	for _, c := range []struct {
+		t      FieldType
+		length uint16
+		value  interface{}
+	}{
+		{Int64, 2, int64(-300)},
+		{Int64, 5, int64(-1 << 35)},
+		{Int16, 1, int16(-128)},
+		{Float64, 4, float64(0.5)},
+	} {
+		bs, err := encodeValue(c.value, c.t, c.length, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bs) != int(c.length) {
+			t.Errorf("%v encoded in %d bytes", c.value, len(bs))
+		}
+		if v := interpretBytes(&bs, c.t); v != c.value {
+			t.Errorf("%v != %v", v, c.value)
+		}
+	}
+}
+
+func TestInterpretNTPTime(t *testing.T) {
+	unix := time.Unix(1500000000, 500000000)
+
+	bs := []byte{0xdd, 0x12, 0xad, 0x80, 0x80, 0, 0x07, 0xff} // the low 11 bits are ignored
+	v := interpretBytes(&bs, DateTimeMicroseconds)
+	if v != interface{}(unix) {
+		t.Errorf("%v != %v", v, unix)
+	}
+
+	bs = []byte{0xdd, 0x12, 0xad, 0x80, 0x80, 0, 0, 0}
+	v = interpretBytes(&bs, DateTimeNanoseconds)
+	if v != interface{}(unix) {
+		t.Errorf("%v != %v", v, unix)
+	}
+
+	// After the NTP seconds wrap in 2036
+	bs = []byte{0, 0, 0, 1, 0, 0, 0, 0}
+	v = interpretBytes(&bs, DateTimeNanoseconds)
+	if want := time.Date(2036, 2, 7, 6, 28, 17, 0, time.UTC); !v.(time.Time).Equal(want) {
+		t.Errorf("%v != %v", v, want)
+	}
+
+	// Zero is unset rather than 2036
+	bs = make([]byte, 8)
+	for _, ft := range []FieldType{DateTimeMicroseconds, DateTimeNanoseconds} {
+		if v := interpretBytes(&bs, ft); !v.(time.Time).IsZero() {
+			t.Error("Incorrect time for zero", v)
+		}
+		if bs, err := encodeValue(time.Time{}, ft, 8, false); err != nil || !bytes.Equal(bs, make([]byte, 8)) {
+			t.Errorf("Incorrect encoding of zero time %x %v", bs, err)
+		}
+	}
+
+	for _, tm := range []time.Time{unix, time.Unix(1600000000, 123456789), time.Unix(2100000000, 999999999)} {
+		bs, err := encodeValue(tm, DateTimeNanoseconds, 8, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := interpretBytes(&bs, DateTimeNanoseconds); !v.(time.Time).Equal(tm) {
+			t.Errorf("%v != %v", v, tm)
+		}
+		bs, err = encodeValue(tm, DateTimeMicroseconds, 8, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bs[7]&0x07 != 0 || bs[6] != bs[6]&0xf8 {
+			t.Errorf("Microsecond fraction not masked %x", bs)
+		}
+		if v := interpretBytes(&bs, DateTimeMicroseconds); !v.(time.Time).Equal(tm.Truncate(time.Microsecond)) {
+			t.Errorf("%v != %v", v, tm.Truncate(time.Microsecond))
+		}
+	}
+}
+
+func TestInterpretUnixTimes(t *testing.T) {
+	s := NewSession()
+	s.LoadTemplateRecords([]TemplateRecord{{
+		TemplateID:      256,
+		FieldSpecifiers: []TemplateFieldSpecifier{{FieldID: 154, Length: 8}, {FieldID: 156, Length: 8}},
+	}})
+	i := NewInterpreter(s, WithUnixTimes())
+	b := i.NewRecordBuilder(256, []TemplateFieldSpecifier{{FieldID: 154, Length: 8}, {FieldID: 156, Length: 8}})
+	unix := time.Unix(1500000000, 123456000)
+	if err := b.Set("flowStartMicroseconds", unix); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Set("flowStartNanoseconds", unix); err != nil {
+		t.Fatal(err)
+	}
+	dr, err := b.Record()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dr.Fields[0], []byte{0, 5, 0x54, 0x3d, 0xf7, 0x2b, 0xa2, 0x40}) {
+		t.Errorf("Incorrect flowStartMicroseconds %x", dr.Fields[0])
+	}
+	for _, f := range i.Interpret(dr) {
+		if v := f.Value.(time.Time); !v.Equal(unix) {
+			t.Errorf("%s %v != %v", f.Name, v, unix)
+		}
+	}
+}
+
+func TestInterpretSemantics(t *testing.T) {
+	s := NewSession()
+	s.LoadTemplateRecords([]TemplateRecord{{
+		TemplateID:      256,
+		FieldSpecifiers: []TemplateFieldSpecifier{{FieldID: 1, Length: 8}, {FieldID: 7, Length: 2}, {FieldID: 48, Length: 1}, {FieldID: 9999, Length: 1}},
+	}})
+	i := NewInterpreter(s)
+	fields := i.Interpret(DataRecord{TemplateID: 256, Fields: [][]byte{make([]byte, 8), {0, 80}, {1}, {1}}})
+
+	if f := fields[0]; f.Semantics != DeltaCounter || f.Units != "octets" || f.Deprecated {
+		t.Error("Incorrect octetDeltaCount", f.Semantics, f.Units, f.Deprecated)
+	}
+	if f := fields[1]; f.Semantics != Identifier || f.Units != "" || f.Deprecated {
+		t.Error("Incorrect sourceTransportPort", f.Semantics, f.Units, f.Deprecated)
+	}
+	if f := fields[2]; !f.Deprecated {
+		t.Error("Incorrect samplerId", f.Deprecated)
+	}
+	if f := fields[3]; f.Semantics != DefaultSemantics || f.Units != "" || f.Deprecated {
+		t.Error("Incorrect unknown field", f.Semantics, f.Units, f.Deprecated)
+	}
+
+	if r := builtinIpfixDictionary[dictionaryKey{0, 195}].Range; r != (ValueRange{0, 63}) {
+		t.Error("Incorrect ipDiffServCodePoint range", r)
+	}
+	if s := TotalCounter.String(); s != "totalCounter" {
+		t.Error("Incorrect semantics name", s)
+	}
+	var sem Semantics
+	if err := sem.UnmarshalText([]byte("flags")); err != nil || sem != Flags {
+		t.Error("Incorrect unmarshalled semantics", sem, err)
+	}
+}
+
 func TestInterpretBool(t *testing.T) {
 	bs := []byte{2}
 	v := interpretBytes(&bs, Boolean)
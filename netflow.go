@@ -0,0 +1,248 @@
+package ipfix
+
+import (
+	"encoding/binary"
+)
+
+// This implements decoding of the fixed format Netflow versions 1, 5 and 7.
+// Their records always have the same layout, so they are described by
+// well-known templates of IPFIX information elements and returned as normal
+// DataRecords. Every field of the record is present in the template, including
+// padding, so that the records can be marshalled back into their original
+// form.
+
+const (
+	netflowV1Version uint16 = 1
+	netflowV5Version uint16 = 5
+	netflowV7Version uint16 = 7
+
+	msgNetflowV1HeaderLength = 2 + 2 + 4 + 4 + 4
+	msgNetflowV5HeaderLength = 2 + 2 + 4 + 4 + 4 + 4 + 1 + 1 + 2
+	msgNetflowV7HeaderLength = 2 + 2 + 4 + 4 + 4 + 4 + 4
+)
+
+// The template IDs of the well-known templates. Data templates always have
+// IDs of 256 and above, so these never collide with templates sent by an
+// exporter or with aliased template IDs.
+const (
+	NetflowV1TemplateID uint16 = 1
+	NetflowV5TemplateID uint16 = 5
+	NetflowV7TemplateID uint16 = 7
+)
+
+// A few fields of Netflow v7 have no IPFIX equivalent. They are given IDs
+// under this enterprise number, 4294967295, which IANA reserves and will
+// never assign to an enterprise.
+const NetflowV7EnterpriseID uint32 = 0xffffffff
+
+const (
+	netflowV7FlagsFieldID          uint16 = 1
+	netflowV7ExtraFlagsFieldID     uint16 = 2
+	netflowV7RouterShortcutFieldID uint16 = 3
+)
+
+// netflowV1Template describes a Netflow v1 flow record.
+var netflowV1Template = []TemplateFieldSpecifier{
+	{FieldID: 8, Length: 4},   // sourceIPv4Address ("srcaddr")
+	{FieldID: 12, Length: 4},  // destinationIPv4Address ("dstaddr")
+	{FieldID: 15, Length: 4},  // ipNextHopIPv4Address ("nexthop")
+	{FieldID: 10, Length: 2},  // ingressInterface ("input")
+	{FieldID: 14, Length: 2},  // egressInterface ("output")
+	{FieldID: 2, Length: 4},   // packetDeltaCount ("dPkts")
+	{FieldID: 1, Length: 4},   // octetDeltaCount ("dOctets")
+	{FieldID: 22, Length: 4},  // flowStartSysUpTime ("first")
+	{FieldID: 21, Length: 4},  // flowEndSysUpTime ("last")
+	{FieldID: 7, Length: 2},   // sourceTransportPort ("srcport")
+	{FieldID: 11, Length: 2},  // destinationTransportPort ("dstport")
+	{FieldID: 210, Length: 2}, // paddingOctets ("pad1")
+	{FieldID: 4, Length: 1},   // protocolIdentifier ("prot")
+	{FieldID: 5, Length: 1},   // ipClassOfService ("tos")
+	{FieldID: 6, Length: 1},   // tcpControlBits ("tcp_flags")
+	{FieldID: 210, Length: 3}, // paddingOctets ("pad2")
+	{FieldID: 210, Length: 4}, // paddingOctets ("reserved")
+}
+
+// netflowV5Template describes a Netflow v5 flow record.
+var netflowV5Template = []TemplateFieldSpecifier{
+	{FieldID: 8, Length: 4},   // sourceIPv4Address ("srcaddr")
+	{FieldID: 12, Length: 4},  // destinationIPv4Address ("dstaddr")
+	{FieldID: 15, Length: 4},  // ipNextHopIPv4Address ("nexthop")
+	{FieldID: 10, Length: 2},  // ingressInterface ("input")
+	{FieldID: 14, Length: 2},  // egressInterface ("output")
+	{FieldID: 2, Length: 4},   // packetDeltaCount ("dPkts")
+	{FieldID: 1, Length: 4},   // octetDeltaCount ("dOctets")
+	{FieldID: 22, Length: 4},  // flowStartSysUpTime ("first")
+	{FieldID: 21, Length: 4},  // flowEndSysUpTime ("last")
+	{FieldID: 7, Length: 2},   // sourceTransportPort ("srcport")
+	{FieldID: 11, Length: 2},  // destinationTransportPort ("dstport")
+	{FieldID: 210, Length: 1}, // paddingOctets ("pad1")
+	{FieldID: 6, Length: 1},   // tcpControlBits ("tcp_flags")
+	{FieldID: 4, Length: 1},   // protocolIdentifier ("prot")
+	{FieldID: 5, Length: 1},   // ipClassOfService ("tos")
+	{FieldID: 16, Length: 2},  // bgpSourceAsNumber ("src_as")
+	{FieldID: 17, Length: 2},  // bgpDestinationAsNumber ("dst_as")
+	{FieldID: 9, Length: 1},   // sourceIPv4PrefixLength ("src_mask")
+	{FieldID: 13, Length: 1},  // destinationIPv4PrefixLength ("dst_mask")
+	{FieldID: 210, Length: 2}, // paddingOctets ("pad2")
+}
+
+// netflowV7Template describes a Netflow v7 (Catalyst switch) flow record.
+var netflowV7Template = []TemplateFieldSpecifier{
+	{FieldID: 8, Length: 4},  // sourceIPv4Address ("srcaddr")
+	{FieldID: 12, Length: 4}, // destinationIPv4Address ("dstaddr")
+	{FieldID: 15, Length: 4}, // ipNextHopIPv4Address ("nexthop")
+	{FieldID: 10, Length: 2}, // ingressInterface ("input")
+	{FieldID: 14, Length: 2}, // egressInterface ("output")
+	{FieldID: 2, Length: 4},  // packetDeltaCount ("dPkts")
+	{FieldID: 1, Length: 4},  // octetDeltaCount ("dOctets")
+	{FieldID: 22, Length: 4}, // flowStartSysUpTime ("first")
+	{FieldID: 21, Length: 4}, // flowEndSysUpTime ("last")
+	{FieldID: 7, Length: 2},  // sourceTransportPort ("srcport")
+	{FieldID: 11, Length: 2}, // destinationTransportPort ("dstport")
+	{EnterpriseID: NetflowV7EnterpriseID, FieldID: netflowV7FlagsFieldID, Length: 1}, // "flags"
+	{FieldID: 6, Length: 1},  // tcpControlBits ("tcp_flags")
+	{FieldID: 4, Length: 1},  // protocolIdentifier ("prot")
+	{FieldID: 5, Length: 1},  // ipClassOfService ("tos")
+	{FieldID: 16, Length: 2}, // bgpSourceAsNumber ("src_as")
+	{FieldID: 17, Length: 2}, // bgpDestinationAsNumber ("dst_as")
+	{FieldID: 9, Length: 1},  // sourceIPv4PrefixLength ("src_mask")
+	{FieldID: 13, Length: 1}, // destinationIPv4PrefixLength ("dst_mask")
+	{EnterpriseID: NetflowV7EnterpriseID, FieldID: netflowV7ExtraFlagsFieldID, Length: 2},     // "flags"
+	{EnterpriseID: NetflowV7EnterpriseID, FieldID: netflowV7RouterShortcutFieldID, Length: 4}, // "router_sc"
+}
+
+// A fixedFormat describes the messages of a fixed format Netflow version.
+type fixedFormat struct {
+	templateID   uint16
+	headerLength int
+	maxRecords   int // per message
+}
+
+var fixedFormats = map[uint16]fixedFormat{
+	netflowV1Version: {NetflowV1TemplateID, msgNetflowV1HeaderLength, 24},
+	netflowV5Version: {NetflowV5TemplateID, msgNetflowV5HeaderLength, 30},
+	netflowV7Version: {NetflowV7TemplateID, msgNetflowV7HeaderLength, 28},
+}
+
+// fixedTemplates maps the well-known template IDs to their templates.
+var fixedTemplates = map[uint16][]TemplateFieldSpecifier{
+	NetflowV1TemplateID: netflowV1Template,
+	NetflowV5TemplateID: netflowV5Template,
+	NetflowV7TemplateID: netflowV7Template,
+}
+
+// isFixedFormat returns true if messages of the given version carry fixed
+// format records rather than sets.
+func isFixedFormat(version uint16) bool {
+	_, ok := fixedFormats[version]
+	return ok
+}
+
+// FixedTemplateRecord returns the well-known template used for the data
+// records of a fixed format Netflow version (1, 5 or 7).
+func FixedTemplateRecord(version uint16) (TemplateRecord, bool) {
+	ff, ok := fixedFormats[version]
+	if !ok {
+		return TemplateRecord{}, false
+	}
+	return TemplateRecord{TemplateID: ff.templateID, FieldSpecifiers: fixedTemplates[ff.templateID]}, true
+}
+
+// unmarshalFixed reads the remainder of a fixed format message header, after
+// the version.
+func (h *MessageHeader) unmarshalFixed(s *slice) {
+	h.Length = s.Uint16()
+	h.SysUptime = s.Uint32()
+	h.ExportTime = s.Uint32()
+	h.ExportNanoseconds = s.Uint32()
+	switch h.Version {
+	case netflowV5Version:
+		h.SequenceNumber = s.Uint32()
+		// The engine type and ID identify the exporting device, much like
+		// the source ID of Netflow v9
+		h.DomainID = uint32(s.Uint8())<<8 | uint32(s.Uint8())
+		h.SamplingInterval = s.Uint16()
+	case netflowV7Version:
+		h.SequenceNumber = s.Uint32()
+		s.Cut(4) // reserved
+	}
+}
+
+func marshalFixedHeader(hdr MessageHeader, totalRecords uint16, buff []byte) {
+	binary.BigEndian.PutUint16(buff[0:2], hdr.Version)
+	binary.BigEndian.PutUint16(buff[2:4], totalRecords)
+	binary.BigEndian.PutUint32(buff[4:8], hdr.SysUptime)
+	binary.BigEndian.PutUint32(buff[8:12], hdr.ExportTime)
+	binary.BigEndian.PutUint32(buff[12:16], hdr.ExportNanoseconds)
+	switch hdr.Version {
+	case netflowV5Version:
+		binary.BigEndian.PutUint32(buff[16:20], hdr.SequenceNumber)
+		buff[20] = uint8(hdr.DomainID >> 8)
+		buff[21] = uint8(hdr.DomainID)
+		binary.BigEndian.PutUint16(buff[22:24], hdr.SamplingInterval)
+	case netflowV7Version:
+		binary.BigEndian.PutUint32(buff[16:20], hdr.SequenceNumber)
+	}
+}
+
+// readFixedRecords reads the records of a fixed format message. The Length
+// field of the header holds the number of records. Errors are returned as a
+// *ParseError, with offsets counted from the start of base.
+func (s *Session) readFixedRecords(base []byte, sl *slice, scope TemplateScope, msg *Message) error {
+	if err := sl.Error(); err != nil {
+		return newParseError(base, base, err)
+	}
+	tid := fixedFormats[msg.Header.Version].templateID
+	tpl := fixedTemplates[tid]
+	drecs := make([]DataRecord, 0, msg.Header.Length)
+	for i := 0; i < int(msg.Header.Length); i++ {
+		start := sl.bytes()
+		dr, err := s.readDataRecord(sl, tpl)
+		if err != nil {
+			if debug {
+				dl.Println("readFixedRecords:", err)
+			}
+			pe := newParseError(base, start, err)
+			pe.TemplateID = tid
+			pe.RecordIndex = i
+			return pe
+		}
+		dr.TemplateID = tid
+		dr.Scope = scope
+		drecs = append(drecs, dr)
+	}
+	msg.DataRecords = drecs
+	return nil
+}
+
+// marshalFixedFormat marshals a fixed format message. Template records are
+// ignored, and every data record must use the well-known template.
+func (m Message) marshalFixedFormat() ([]byte, error) {
+	ff := fixedFormats[m.Header.Version]
+	tpl := fixedTemplates[ff.templateID]
+	if len(m.OptionsDataRecords) > 0 {
+		return nil, ErrProtocol
+	}
+
+	message := make([]byte, ff.headerLength+int(calcMinRecLen(tpl))*len(m.DataRecords))
+	marshalFixedHeader(m.Header, uint16(len(m.DataRecords)), message)
+
+	offset := ff.headerLength
+	for _, dr := range m.DataRecords {
+		if dr.TemplateID != ff.templateID {
+			return nil, ErrUnknownTemplate
+		}
+		if len(dr.Fields) > len(tpl) {
+			return nil, ErrTooManyTemplates
+		} else if len(dr.Fields) < len(tpl) {
+			return nil, ErrProtocol
+		}
+		for i, field := range dr.Fields {
+			if len(field) != int(tpl[i].Length) {
+				return nil, ErrFieldOverflow
+			}
+			offset += copy(message[offset:], field)
+		}
+	}
+	return message, nil
+}
@@ -0,0 +1,258 @@
+package ipfix
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net"
+	"testing"
+)
+
+var netflowV5Packet, _ = hex.DecodeString("00050002000010005685b370000000100000006401024064c0a800c9c0a8000100000000000100020000000a0000040000000f00000010001f90c35000180600fde8fde9181000000a0000010a0000020a0000fe00030004000000010000004000000f1000000f200035d431000011000000000008080000")
+
+func TestParseNetflowV5(t *testing.T) {
+	p := NewSession()
+	msg, err := p.ParseBuffer(netflowV5Packet)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+
+	want := MessageHeader{
+		Version:           5,
+		Length:            2,
+		SysUptime:         0x1000,
+		ExportTime:        0x5685b370,
+		ExportNanoseconds: 0x10,
+		SequenceNumber:    100,
+		DomainID:          0x0102,
+		SamplingInterval:  0x4064,
+	}
+	if msg.Header != want {
+		t.Errorf("Incorrect header %+v, want %+v", msg.Header, want)
+	}
+	if len(msg.DataRecords) != 2 {
+		t.Fatal("Incorrect number of data records", len(msg.DataRecords))
+	}
+	if len(msg.TemplateRecords) != 0 {
+		t.Error("Incorrect number of template records", len(msg.TemplateRecords))
+	}
+	for _, dr := range msg.DataRecords {
+		if dr.TemplateID != NetflowV5TemplateID {
+			t.Error("Incorrect template ID", dr.TemplateID)
+		}
+		if len(dr.Fields) != len(netflowV5Template) {
+			t.Error("Incorrect number of fields", len(dr.Fields))
+		}
+	}
+
+	i := NewInterpreter(p)
+	fields := i.Interpret(msg.DataRecords[0])
+	values := make(map[string]interface{})
+	for _, f := range fields {
+		values[f.Name] = f.Value
+	}
+	if ip, ok := values["sourceIPv4Address"].(*net.IP); !ok || !ip.Equal(net.IPv4(192, 168, 0, 201)) {
+		t.Error("Incorrect sourceIPv4Address", values["sourceIPv4Address"])
+	}
+	if v := values["destinationTransportPort"]; v != uint16(50000) {
+		t.Error("Incorrect destinationTransportPort", v)
+	}
+	if v := values["ingressInterface"]; v != uint32(1) {
+		t.Error("Incorrect ingressInterface", v)
+	}
+	if v := values["bgpDestinationAsNumber"]; v != uint32(65001) {
+		t.Error("Incorrect bgpDestinationAsNumber", v)
+	}
+
+	trecs, err := p.LookupTemplateRecords(msg)
+	if err != nil {
+		t.Fatal("LookupTemplateRecords failed", err)
+	}
+	if len(trecs) != 1 || trecs[0].TemplateID != NetflowV5TemplateID {
+		t.Errorf("Incorrect template records %+v", trecs)
+	}
+}
+
+func TestMarshalNetflowV5(t *testing.T) {
+	p := NewSession()
+	msg, err := p.ParseBuffer(netflowV5Packet)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+
+	bs, err := p.Marshal(msg)
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	if !bytes.Equal(bs, netflowV5Packet) {
+		t.Errorf("Marshalled message differs\n%x\n%x", bs, netflowV5Packet)
+	}
+
+	bs, err = msg.Marshal()
+	if err != nil {
+		t.Fatal("Message.Marshal failed", err)
+	}
+	if !bytes.Equal(bs, netflowV5Packet) {
+		t.Errorf("Marshalled message differs\n%x\n%x", bs, netflowV5Packet)
+	}
+
+	msg.DataRecords[0].Fields[0] = []byte{10}
+	if _, err = msg.Marshal(); err != ErrFieldOverflow {
+		t.Error("Expected ErrFieldOverflow, got", err)
+	}
+}
+
+func TestParseNetflowV5Errors(t *testing.T) {
+	p := NewSession()
+	if _, err := p.ParseBuffer(netflowV5Packet[:len(netflowV5Packet)-1]); !errors.Is(err, ErrRead) {
+		t.Error("Expected ErrRead for a truncated packet, got", err)
+	}
+
+	bs := append([]byte(nil), netflowV5Packet...)
+	bs[1] = 6
+	if _, err := p.ParseBuffer(bs); err != ErrVersion {
+		t.Error("Expected ErrVersion, got", err)
+	}
+}
+
+func TestNetflowV5Walk(t *testing.T) {
+	var f Filter
+	f.SetVersion(5)
+	f.Set(0, 8)  // sourceIPv4Address
+	f.Set(0, 11) // destinationTransportPort
+
+	var vals []cbval
+	var records int
+	cb := func(r *Record, eid uint32, fid uint16, buff []byte) error {
+		if r.EndOfRecord {
+			records++
+			return nil
+		}
+		vals = append(vals, cbval{eid: eid, fid: fid, data: buff})
+		return nil
+	}
+
+	w, err := NewWalker(&f, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WalkBuffer(netflowV5Packet, cb); err != nil {
+		t.Fatal(err)
+	}
+	if records != 2 {
+		t.Fatal("Incorrect number of records", records)
+	}
+	want := []cbval{
+		{fid: 8, data: []byte{192, 168, 0, 201}},
+		{fid: 11, data: []byte{0xc3, 0x50}},
+		{fid: 8, data: []byte{10, 0, 0, 1}},
+		{fid: 11, data: []byte{0xd4, 0x31}},
+	}
+	if len(vals) != len(want) {
+		t.Fatalf("Incorrect number of callbacks %d != %d", len(vals), len(want))
+	}
+	for i := range want {
+		if vals[i].fid != want[i].fid || !bytes.Equal(vals[i].data, want[i].data) {
+			t.Errorf("Callback %d: got %+v, want %+v", i, vals[i], want[i])
+		}
+	}
+
+	if err = w.WalkBuffer(netflowV5Packet[:len(netflowV5Packet)-1], cb); !errors.Is(err, ErrRead) {
+		t.Error("Expected ErrRead for a truncated packet, got", err)
+	}
+}
+
+func TestParseNetflowV1V7(t *testing.T) {
+	v1, _ := hex.DecodeString("00010001000020005685b37100000020c0a800c9c0a80001c0a800fe000100020000000a0000040000000f00000010001f90c350000006001800000000000000")
+	v7, _ := hex.DecodeString("00070001000030005685b37200000030000000c8000000000a0000010a0000020a0000fe00030004000000010000004000000f1000000f200035d43101001100fde8fde908180102c0a80101")
+
+	tests := []struct {
+		packet []byte
+		header MessageHeader
+		tid    uint16
+		values map[string]interface{}
+	}{
+		{
+			packet: v1,
+			header: MessageHeader{Version: 1, Length: 1, SysUptime: 0x2000, ExportTime: 0x5685b371, ExportNanoseconds: 0x20},
+			tid:    NetflowV1TemplateID,
+			values: map[string]interface{}{
+				"protocolIdentifier":       uint8(6),
+				"tcpControlBits":           uint16(0x18),
+				"destinationTransportPort": uint16(50000),
+				"flowEndSysUpTime":         uint32(0x1000),
+			},
+		},
+		{
+			packet: v7,
+			header: MessageHeader{Version: 7, Length: 1, SysUptime: 0x3000, ExportTime: 0x5685b372, ExportNanoseconds: 0x30, SequenceNumber: 200},
+			tid:    NetflowV7TemplateID,
+			values: map[string]interface{}{
+				"protocolIdentifier":          uint8(17),
+				"bgpSourceAsNumber":           uint32(65000),
+				"netflowV7Flags":              uint8(1),
+				"netflowV7ExtraFlags":         uint16(0x0102),
+				"destinationIPv4PrefixLength": uint8(24),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		p := NewSession()
+		msg, err := p.ParseBuffer(tc.packet)
+		if err != nil {
+			t.Fatalf("Version %d: ParseBuffer failed: %v", tc.header.Version, err)
+		}
+		if msg.Header != tc.header {
+			t.Errorf("Incorrect header %+v, want %+v", msg.Header, tc.header)
+		}
+		if len(msg.DataRecords) != 1 {
+			t.Fatal("Incorrect number of data records", len(msg.DataRecords))
+		}
+		if msg.DataRecords[0].TemplateID != tc.tid {
+			t.Error("Incorrect template ID", msg.DataRecords[0].TemplateID)
+		}
+
+		values := make(map[string]interface{})
+		for _, f := range NewInterpreter(p).Interpret(msg.DataRecords[0]) {
+			values[f.Name] = f.Value
+		}
+		for name, want := range tc.values {
+			if values[name] != want {
+				t.Errorf("Version %d: incorrect %s %v (%T), want %v (%T)", tc.header.Version, name, values[name], values[name], want, want)
+			}
+		}
+
+		bs, err := p.Marshal(msg)
+		if err != nil {
+			t.Fatal("Marshal failed", err)
+		}
+		if !bytes.Equal(bs, tc.packet) {
+			t.Errorf("Marshalled message differs\n%x\n%x", bs, tc.packet)
+		}
+	}
+}
+
+func TestNetflowV7WalkRouterShortcut(t *testing.T) {
+	v7, _ := hex.DecodeString("00070001000030005685b37200000030000000c8000000000a0000010a0000020a0000fe00030004000000010000004000000f1000000f200035d43101001100fde8fde908180102c0a80101")
+
+	var f Filter
+	f.Set(NetflowV7EnterpriseID, netflowV7RouterShortcutFieldID)
+	var got []byte
+	cb := func(r *Record, eid uint32, fid uint16, buff []byte) error {
+		if eid == NetflowV7EnterpriseID && fid == netflowV7RouterShortcutFieldID {
+			got = buff
+		}
+		return nil
+	}
+	w, err := NewWalker(&f, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WalkBuffer(v7, cb); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte{192, 168, 1, 1}) {
+		t.Errorf("Incorrect router_sc %v", got)
+	}
+}
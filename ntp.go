@@ -0,0 +1,55 @@
+package ipfix
+
+import (
+	"time"
+)
+
+// ntpEpochOffset is the number of seconds from the NTP epoch, 1 January 1900,
+// to the Unix epoch.
+const ntpEpochOffset = 2208988800
+
+// microsecondMask clears the bits of an NTP fraction finer than a
+// microsecond, which must be ignored in dateTimeMicroseconds (RFC 7011
+// section 6.1.9).
+const microsecondMask = ^uint64(0x7ff)
+
+// ntpTime decodes a 64 bit NTP timestamp of the given precision, which is
+// time.Microsecond or time.Nanosecond. Following RFC 4330 section 3, the era
+// pivots on the most significant bit of the seconds: times with it set are
+// from 1968 to 2036, times with it clear from 2036 to 2104. A timestamp of
+// zero, which exporters send for times they do not know, is decoded as the
+// zero time.Time rather than as 2036.
+func ntpTime(v uint64, precision time.Duration) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	secs := int64(v >> 32)
+	if secs < 1<<31 {
+		secs += 1 << 32
+	}
+	if precision == time.Microsecond {
+		v &= microsecondMask
+	}
+	// Round to the precision, the fraction is never exact
+	frac := v & 0xffffffff
+	units := uint64(time.Second / precision)
+	n := (frac*units + 1<<31) >> 32
+	return time.Unix(secs-ntpEpochOffset, int64(n)*int64(precision))
+}
+
+// ntpValue encodes t as a 64 bit NTP timestamp of the given precision. The
+// zero time.Time is encoded as zero, the reverse of ntpTime.
+func ntpValue(t time.Time, precision time.Duration) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	secs := uint64(t.Unix()+ntpEpochOffset) & 0xffffffff
+	units := uint64(time.Second / precision)
+	n := uint64(t.Nanosecond()) / uint64(precision)
+	if precision == time.Microsecond {
+		// Round to the nearest representable fraction
+		frac := (n<<21 + units/2) / units
+		return secs<<32 | frac<<11
+	}
+	return secs<<32 | (n<<32+units-1)/units
+}
@@ -0,0 +1,84 @@
+package ipfix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ParseError describes where in a buffer parsing failed. It wraps one of
+// the errors above (ErrRead, ErrProtocol, ErrUnknownTemplate or
+// io.ErrUnexpectedEOF), so errors.Is can be used to check the cause.
+//
+// Offset is counted from the start of the buffer passed to the parsing
+// function. The indexes count from zero and are -1 if they do not apply, for
+// example when a set header is malformed there is no record or field. The
+// TemplateID is zero if it is not known.
+type ParseError struct {
+	Offset      int
+	SetIndex    int
+	SetID       uint16
+	TemplateID  uint16
+	RecordIndex int
+	FieldIndex  int
+	Err         error
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v at offset %d", e.Err, e.Offset)
+	if e.SetIndex >= 0 {
+		fmt.Fprintf(&b, ", set %d (ID %d)", e.SetIndex, e.SetID)
+	}
+	if e.TemplateID != 0 {
+		fmt.Fprintf(&b, ", template %d", e.TemplateID)
+	}
+	if e.RecordIndex >= 0 {
+		fmt.Fprintf(&b, ", record %d", e.RecordIndex)
+	}
+	if e.FieldIndex >= 0 {
+		fmt.Fprintf(&b, ", field %d", e.FieldIndex)
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError returns a ParseError for an error detected at the start of
+// at, which must have been cut from base. If err is a fieldError its position
+// is used instead.
+func newParseError(base, at []byte, err error) *ParseError {
+	pe := &ParseError{
+		Offset:      offsetOf(base, at),
+		SetIndex:    -1,
+		RecordIndex: -1,
+		FieldIndex:  -1,
+		Err:         err,
+	}
+	if fe, ok := err.(*fieldError); ok {
+		pe.Offset = offsetOf(base, fe.at)
+		pe.FieldIndex = fe.index
+		pe.Err = fe.err
+	}
+	return pe
+}
+
+// offsetOf returns the position of at within base. Both slices end at the
+// end of the same array, so the difference of their capacities is the offset.
+func offsetOf(base, at []byte) int {
+	return cap(base) - cap(at)
+}
+
+// A fieldError is returned by the record readers when a field can not be
+// read. at holds the remainder of the buffer, starting with the field.
+type fieldError struct {
+	index int
+	at    []byte
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return fmt.Sprintf("field %d: %v", e.index, e.err)
+}
@@ -0,0 +1,86 @@
+package ipfix
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// Template 257 with a fixed and a variable length field, followed by a data
+// set whose second field claims more bytes than the set holds.
+var truncatedFieldPacket, _ = hex.DecodeString("000a002b5685b37000000000000000010002001001010002000800040052ffff0101000bc0a800c9056162")
+
+func checkParseError(t *testing.T, err error, want ParseError) {
+	t.Helper()
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+	if !errors.Is(err, want.Err) {
+		t.Errorf("Incorrect error %v, want %v", pe.Err, want.Err)
+	}
+	want.Err = pe.Err
+	if *pe != want {
+		t.Errorf("Incorrect ParseError %+v, want %+v", *pe, want)
+	}
+}
+
+func TestParseErrorField(t *testing.T) {
+	want := ParseError{Offset: 40, SetIndex: 1, SetID: 257, TemplateID: 257, RecordIndex: 0, FieldIndex: 1, Err: ErrRead}
+
+	_, err := NewSession().ParseBuffer(truncatedFieldPacket)
+	checkParseError(t, err, want)
+	if s := err.Error(); s != "short read - malformed packet? at offset 40, set 1 (ID 257), template 257, record 0, field 1" {
+		t.Error("Incorrect error string", s)
+	}
+
+	// Offsets are counted from the start of the whole buffer
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	msgs, err := NewSession().ParseBufferAll(append(pA, truncatedFieldPacket...))
+	if len(msgs) != 1 {
+		t.Error("Incorrect number of messages", len(msgs))
+	}
+	want.Offset += len(pA)
+	checkParseError(t, err, want)
+}
+
+func TestParseErrorTemplate(t *testing.T) {
+	// A template record announcing three fields but holding two
+	packet, _ := hex.DecodeString("000a00205685b3700000000000000001000200100100000300080004000c0004")
+	_, err := NewSession().ParseBuffer(packet)
+	checkParseError(t, err, ParseError{Offset: 20, SetIndex: 0, SetID: 2, TemplateID: 256, RecordIndex: 0, FieldIndex: -1, Err: ErrRead})
+
+	// A set overrunning the message
+	packet, _ = hex.DecodeString("000a00185685b370000000000000000101000010c0a800c9")
+	_, err = NewSession().ParseBuffer(packet)
+	checkParseError(t, err, ParseError{Offset: 16, SetIndex: 0, SetID: 256, RecordIndex: -1, FieldIndex: -1, Err: ErrRead})
+}
+
+func TestParseErrorLenient(t *testing.T) {
+	p := NewSession(WithLenientParsing(true))
+	msg, err := p.ParseBuffer(truncatedFieldPacket)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(msg.Errors) != 1 {
+		t.Fatal("Incorrect number of errors", len(msg.Errors))
+	}
+	checkParseError(t, msg.Errors[0], ParseError{Offset: 40, SetIndex: 1, SetID: 257, TemplateID: 257, RecordIndex: 0, FieldIndex: 1, Err: ErrRead})
+}
+
+func TestParseErrorWalker(t *testing.T) {
+	w, err := NewWalker(nil, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb := func(*Record, uint32, uint16, []byte) error { return nil }
+	err = w.WalkBuffer(truncatedFieldPacket, cb)
+	checkParseError(t, err, ParseError{Offset: 40, SetIndex: 1, SetID: 257, TemplateID: 257, RecordIndex: 0, FieldIndex: 1, Err: ErrRead})
+
+	// Errors from the callback are not wrapped
+	errStop := errors.New("stop")
+	cb = func(*Record, uint32, uint16, []byte) error { return errStop }
+	if err = w.WalkBuffer(walkerPkt, cb); err != errStop {
+		t.Error("Expected the callback error, got", err)
+	}
+}
@@ -3,10 +3,14 @@ package ipfix
 import (
 	"bytes"
 	"crypto/sha1"
+	"crypto/x509"
 	"encoding/binary"
 	"errors"
 	"io"
+	"net"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -40,29 +44,53 @@ var ErrTooManyTemplates = errors.New("too many fields for template")
 // this is typically due to some corruption in a field and/or template spec
 var ErrFieldOverflow = errors.New("encoded field overflows message")
 
+// ErrMessageTooLong is returned by Marshal when a message would be longer than
+// the 65535 bytes its header can express. Use MarshalSplit to spread the
+// records over several messages.
+var ErrMessageTooLong = errors.New("message too long")
+
 // A Message is the top level construct representing an IPFIX message. A well
 // formed message contains one or more sets of data or template information.
+//
+// Errors holds the errors of the sets which were skipped while parsing in
+// lenient mode, see WithLenientParsing. Each is a *ParseError. It is always
+// empty in strict mode.
+//
+// PeerCertificate is the certificate of the exporter for messages received
+// over an authenticated TLS connection, see Collector.ServeTLS.
 type Message struct {
-	Header          MessageHeader
-	DataRecords     []DataRecord
-	TemplateRecords []TemplateRecord
+	Header                 MessageHeader
+	DataRecords            []DataRecord
+	TemplateRecords        []TemplateRecord
+	OptionsDataRecords     []OptionsDataRecord
+	OptionsTemplateRecords []OptionsTemplateRecord
+	Errors                 []error
+	PeerCertificate        *x509.Certificate
 }
 
 // The MessageHeader provides metadata for the entire Message. The sequence
 // number and domain ID can be used to gain knowledge of messages lost on an
 // unreliable transport such as UDP.
+//
+// Netflow v1, v5, v7 and v9 messages carry the record count in Length.
+// Netflow v1 has no sequence number, and Netflow v5 carries the engine type
+// and engine ID in the upper and lower byte of DomainID.
 type MessageHeader struct {
-	Version        uint16 // Always 0x09 or 0x0a
-	Length         uint16
-	SysUptime      uint32 // NetflowV9 only
-	ExportTime     uint32 // Epoch seconds
-	SequenceNumber uint32
-	DomainID       uint32 // "source ID" in netflow v9
+	Version           uint16 // 0x01, 0x05, 0x07, 0x09 or 0x0a
+	Length            uint16
+	SysUptime         uint32 // Netflow only
+	ExportTime        uint32 // Epoch seconds
+	ExportNanoseconds uint32 // Netflow v1, v5 and v7 only
+	SequenceNumber    uint32
+	DomainID          uint32 // "source ID" in netflow v9
+	SamplingInterval  uint16 // Netflow v5 only
 }
 
 func (h *MessageHeader) unmarshal(s *slice) {
 	h.Version = s.Uint16()
-	if h.Version == nfv9Version {
+	if isFixedFormat(h.Version) {
+		h.unmarshalFixed(s)
+	} else if h.Version == nfv9Version {
 		h.Length = s.Uint16()
 		h.SysUptime = s.Uint32()
 		h.ExportTime = s.Uint32()
@@ -96,20 +124,80 @@ func (h *templateHeader) unmarshal(s *slice) {
 	h.FieldCount = s.Uint16()
 }
 
+type nfv9OptionsTemplateHeader struct {
+	TemplateID   uint16
+	ScopeLength  uint16
+	OptionLength uint16
+}
+
+func (h *nfv9OptionsTemplateHeader) unmarshal(s *slice) {
+	h.TemplateID = s.Uint16()
+	h.ScopeLength = s.Uint16()
+	h.OptionLength = s.Uint16()
+}
+
+// A TemplateScope identifies the exporter and observation domain a template
+// belongs to. Template IDs are only unique within a scope, so two exporters
+// (or two observation domains on the same exporter) may use the same template
+// ID for different templates.
+type TemplateScope struct {
+	Exporter string // Transport address of the exporter, empty if unknown
+	DomainID uint32 // "source ID" in netflow v9
+}
+
 // The DataRecord represents a single exported flow. The Fields each describe
 // different aspects of the flow (source and destination address, counters,
 // service, etc.).
 type DataRecord struct {
 	TemplateID uint16
+	Scope      TemplateScope
 	Fields     [][]byte
 }
 
 // The TemplateRecord describes a data template, as used by DataRecords.
 type TemplateRecord struct {
 	TemplateID      uint16
+	Scope           TemplateScope
 	FieldSpecifiers []TemplateFieldSpecifier
 }
 
+// The OptionsDataRecord represents a single record exported using an options
+// template, such as a sampler, interface or application table entry. The
+// ScopeFields identify what the record applies to and the Fields carry the
+// option values themselves.
+type OptionsDataRecord struct {
+	TemplateID  uint16
+	Scope       TemplateScope
+	ScopeFields [][]byte
+	Fields      [][]byte
+}
+
+// The OptionsTemplateRecord describes an options template, as used by
+// OptionsDataRecords. The first ScopeFieldCount entries of FieldSpecifiers are
+// the scope fields, the remainder are the option fields.
+type OptionsTemplateRecord struct {
+	TemplateID      uint16
+	Scope           TemplateScope
+	ScopeFieldCount uint16
+	FieldSpecifiers []TemplateFieldSpecifier
+}
+
+// ScopeFieldSpecifiers returns the field specifiers of the scope fields.
+func (otr OptionsTemplateRecord) ScopeFieldSpecifiers() []TemplateFieldSpecifier {
+	if int(otr.ScopeFieldCount) > len(otr.FieldSpecifiers) {
+		return otr.FieldSpecifiers
+	}
+	return otr.FieldSpecifiers[:otr.ScopeFieldCount]
+}
+
+// OptionFieldSpecifiers returns the field specifiers of the non-scope fields.
+func (otr OptionsTemplateRecord) OptionFieldSpecifiers() []TemplateFieldSpecifier {
+	if int(otr.ScopeFieldCount) > len(otr.FieldSpecifiers) {
+		return nil
+	}
+	return otr.FieldSpecifiers[otr.ScopeFieldCount:]
+}
+
 // The TemplateFieldSpecifier describes the ID and size of the corresponding
 // Fields in a DataRecord.
 type TemplateFieldSpecifier struct {
@@ -128,20 +216,77 @@ func WithIDAliasing(v bool) Option {
 	}
 }
 
+// WithLenientParsing enables or disables lenient parsing. The default is
+// disabled, so that the first malformed set fails the whole message.
+//
+// In lenient mode malformed sets and data sets with an unknown template are
+// skipped using their set length, and sets with a reserved set ID (4-255) are
+// ignored. The message is returned with all records which could be decoded,
+// and the errors of the skipped sets are listed in Message.Errors. Parsing
+// only stops early if a set header is truncated or claims more bytes than the
+// message holds.
+func WithLenientParsing(v bool) Option {
+	return func(s *Session) {
+		s.lenient = v
+	}
+}
+
+// WithTemplateTimeout sets the lifetime of templates which are not refreshed
+// by the exporter, see ExpireTemplates. RFC 7011 and RFC 3954 require this for
+// unreliable transports such as UDP. The default of zero means templates never
+// expire.
+func WithTemplateTimeout(d time.Duration) Option {
+	return func(s *Session) {
+		s.templateTimeout = d
+	}
+}
+
+// WithSequenceCallback sets a function which is called whenever a gap,
+// duplicate, reordering or restart is detected in the sequence numbers of an
+// exporter. The callback is called synchronously from the parsing functions.
+func WithSequenceCallback(cb SequenceCallback) Option {
+	return func(s *Session) {
+		s.sequenceCallback = cb
+	}
+}
+
 // The Session is the context for IPFIX messages.
 type Session struct {
 	buffers *sync.Pool
 
-	withIDAliasing bool
-
-	version uint16
+	withIDAliasing   bool
+	lenient          bool
+	templateTimeout  time.Duration
+	sequenceCallback SequenceCallback
+
+	version uint32 // accessed atomically
+
+	mut         sync.RWMutex
+	minRecord   map[templateKey]uint16
+	signatures  map[[sha1.Size]byte]uint16
+	specifiers  map[templateKey][]TemplateFieldSpecifier
+	scopeCounts map[templateKey]uint16
+	aliases     map[templateKey]uint16
+	refreshed   map[templateKey]time.Time
+	nextID      uint16
+
+	seqMut    sync.Mutex
+	sequences map[TemplateScope]*sequenceState
+
+	pendingMaxBytes int
+	pendingMaxAge   time.Duration
+	pendingCallback PendingCallback
+	pendMut         sync.Mutex
+	pending         map[TemplateScope]*pendingQueue
+	replayed        []replayedSet
+	replayedBytes   int
+}
 
-	mut        sync.RWMutex
-	minRecord  map[uint16]uint16
-	signatures map[[sha1.Size]byte]uint16
-	specifiers map[uint16][]TemplateFieldSpecifier
-	aliases    map[uint16]uint16
-	nextID     uint16
+// templateKey identifies a template within the session. When ID aliasing is
+// enabled templates are stored under their alias with an empty scope.
+type templateKey struct {
+	scope TemplateScope
+	id    uint16
 }
 
 // NewSession initializes a new Session based on the provided io.Reader.
@@ -159,27 +304,35 @@ func NewSession(opts ...Option) *Session {
 
 	if s.withIDAliasing {
 		s.signatures = make(map[[sha1.Size]byte]uint16)
-		s.aliases = make(map[uint16]uint16)
+		s.aliases = make(map[templateKey]uint16)
 		s.nextID = 256
 	}
 
-	s.specifiers = make(map[uint16][]TemplateFieldSpecifier)
-	s.minRecord = make(map[uint16]uint16)
+	s.specifiers = make(map[templateKey][]TemplateFieldSpecifier)
+	s.scopeCounts = make(map[templateKey]uint16)
+	s.minRecord = make(map[templateKey]uint16)
+	s.refreshed = make(map[templateKey]time.Time)
+	s.sequences = make(map[TemplateScope]*sequenceState)
+	s.pending = make(map[TemplateScope]*pendingQueue)
 
 	return &s
 }
 
 const (
-	msgIpfixHeaderLength = 2 + 2 + 4 + 4 + 4
-	msgNFv9HeaderLength  = 2 + 2 + 4 + 4 + 4 + 4
-	setHeaderLength      = 2 + 2
+	msgIpfixHeaderLength            = 2 + 2 + 4 + 4 + 4
+	msgNFv9HeaderLength             = 2 + 2 + 4 + 4 + 4 + 4
+	setHeaderLength                 = 2 + 2
+	templateHeaderLength            = 2 + 2
+	nfv9OptionsTemplateHeaderLength = 2 + 2 + 2
 )
 
 // Version returns the Netflow/IPFIX version seen in the most recent header.
-// It returns 0x09 for Netflow v9 and 0x0a for IPFIX.
+// It returns 0x09 for Netflow v9 and 0x0a for IPFIX. Fixed format Netflow
+// versions also return 0x0a, since their records use IPFIX information
+// elements.
 // It defaults to IPFIX (0x0a) if no messages have been parsed yet.
 func (s *Session) Version() uint16 {
-	if s.version == 0x09 {
+	if atomic.LoadUint32(&s.version) == 0x09 {
 		return 0x09
 	}
 	return 0x0a
@@ -190,7 +343,8 @@ func (s *Session) Version() uint16 {
 // recoverable -- once an error has been returned, ParseReader should not be
 // called again on the same session.
 //
-// Deprecated: use ParseBuffer instead. ParseReader does not support Netflow v9
+// Deprecated: use ParseBuffer or a StreamDecoder instead. ParseReader does
+// not support Netflow v9
 func (s *Session) ParseReader(r io.Reader) (Message, error) {
 	bs := s.buffers.Get().([]byte)
 	bs, hdr, err := Read(r, bs)
@@ -202,55 +356,157 @@ func (s *Session) ParseReader(r io.Reader) (Message, error) {
 	var msg Message
 	msg.Header = hdr
 
-	msg.TemplateRecords, msg.DataRecords, err = s.readBuffer(sl)
+	scope := TemplateScope{DomainID: hdr.DomainID}
+	unknownSets, err := s.readBuffer(bs, sl, scope, &msg)
+	if err == nil {
+		s.trackSequence(scope, msg, unknownSets == 0)
+	}
+	s.replayParsed(scope, msg, err)
 	s.buffers.Put(bs)
 	return msg, err
 }
 
-// ParseBuffer extracts one message (IPFIX or Netflow V9) from the given buffer and returns it.
+// ParseBuffer extracts one message (IPFIX, Netflow V9, V7, V5 or V1) from the given buffer and returns it.
 // Err is nil if the buffer could be parsed correctly. ParseBuffer is goroutine safe.
+//
+// Malformed messages are reported as a *ParseError describing where parsing
+// failed, an unknown version as ErrVersion.
+//
+// Netflow V7, V5 and V1 records are returned as DataRecords using the
+// well-known template returned by FixedTemplateRecord.
+//
+// Templates are scoped by the observation domain only, use ParseBufferFrom
+// if the session receives messages from more than one exporter.
 func (s *Session) ParseBuffer(bs []byte) (Message, error) {
+	return s.parseBuffer(bs, bs, "")
+}
+
+// ParseBufferFrom works like ParseBuffer, but scopes the templates by the
+// transport address of the exporter as well as the observation domain. This
+// allows a single Session to receive messages from any number of exporters.
+func (s *Session) ParseBufferFrom(bs []byte, addr net.Addr) (Message, error) {
+	var exporter string
+	if addr != nil {
+		exporter = addr.String()
+	}
+	return s.parseBuffer(bs, bs, exporter)
+}
+
+// parseBuffer parses the message in bs, which was cut from base. Error
+// offsets are counted from the start of base.
+func (s *Session) parseBuffer(base, bs []byte, exporter string) (Message, error) {
 	var msg Message
-	var err error
 
 	sl := newSlice(bs)
 	msg.Header.unmarshal(sl)
-	msg.TemplateRecords, msg.DataRecords, err = s.readBuffer(sl)
+	scope := TemplateScope{Exporter: exporter, DomainID: msg.Header.DomainID}
+	var unknownSets int
+	var err error
+	switch {
+	case msg.Header.Version == ipfixVersion || msg.Header.Version == nfv9Version:
+		if err = sl.Error(); err != nil {
+			return msg, newParseError(base, bs, err)
+		}
+		unknownSets, err = s.readBuffer(base, sl, scope, &msg)
+	case isFixedFormat(msg.Header.Version):
+		err = s.readFixedRecords(base, sl, scope, &msg)
+	default:
+		return msg, ErrVersion
+	}
+	if err == nil {
+		s.trackSequence(scope, msg, unknownSets == 0)
+	}
+	s.replayParsed(scope, msg, err)
 	// Set the version to the last-seen value
-	s.version = msg.Header.Version
+	atomic.StoreUint32(&s.version, uint32(msg.Header.Version))
 	return msg, err
 }
 
-// ParseBufferAll extracts all message from the given buffer and returns them.
-// Err is nil if the buffer could be parsed correctly. ParseBufferAll is
-// goroutine safe.
-// ParseBufferAll does not currently support NFv9
+// ParseBufferAll extracts all messages (IPFIX or Netflow V9) from the given
+// buffer and returns them. Err is nil if the buffer could be parsed correctly.
+// ParseBufferAll is goroutine safe. Errors are reported as by ParseBuffer,
+// with offsets counted from the start of bs, and the messages before the
+// failing one are returned.
+//
+// IPFIX messages are framed by the length in their header, NFv9 packets by
+// their record count, see StreamDecoder.
 func (s *Session) ParseBufferAll(bs []byte) ([]Message, error) {
 	var msgs []Message
-	var err error
 
-	sl := newSlice(bs)
-
-	for sl.Len() > 0 {
-		var msg Message
-		msg.Header.unmarshal(sl)
-		length := int(msg.Header.Length - msgIpfixHeaderLength)
-		cut := newSlice(sl.Cut(length))
-		if msg.TemplateRecords, msg.DataRecords, err = s.readBuffer(cut); err != nil {
-			break
+	for rest := bs; len(rest) > 0; {
+		var n int
+		var err error
+		if len(rest) < 2 {
+			return msgs, newParseError(bs, rest, ErrRead)
+		}
+		switch binary.BigEndian.Uint16(rest) {
+		case ipfixVersion:
+			n = msgIpfixHeaderLength
+			if len(rest) >= msgIpfixHeaderLength {
+				if n = int(binary.BigEndian.Uint16(rest[2:])); n < msgIpfixHeaderLength {
+					err = ErrProtocol
+				}
+			}
+		case nfv9Version:
+			n, err = s.frameNFv9(bytesPeeker(rest), "")
+		default:
+			return msgs, ErrVersion
+		}
+		if (err == nil && n > len(rest)) || err == io.EOF {
+			err = ErrRead
+		}
+		if err != nil {
+			return msgs, newParseError(bs, rest, err)
 		}
 
+		msg, err := s.parseBuffer(bs, rest[:n], "")
+		if err != nil {
+			return msgs, err
+		}
 		msgs = append(msgs, msg)
+		rest = rest[n:]
 	}
-	return msgs, err
+	return msgs, nil
 }
 
-func (s *Session) readBuffer(sl *slice) ([]TemplateRecord, []DataRecord, error) {
-	var ts, trecs []TemplateRecord
-	var ds, drecs []DataRecord
-	var err error
+// setRecords collects the records of all sets in a message.
+type setRecords struct {
+	Message
+	unknownSets int    // data sets skipped because of an unknown template or error
+	base        []byte // the buffer the sets were cut from, for error offsets
+	setIndex    int    // index of the set being read
+}
+
+// setError returns a ParseError for an error in the set starting at at.
+func (recs *setRecords) setError(at []byte, setHdr setHeader, err error) *ParseError {
+	pe := newParseError(recs.base, at, err)
+	pe.SetIndex = recs.setIndex
+	pe.SetID = setHdr.SetID
+	return pe
+}
+
+// recordError returns a ParseError for an error in the record starting at at.
+func (recs *setRecords) recordError(at []byte, setHdr setHeader, tid uint16, record int, err error) *ParseError {
+	pe := recs.setError(at, setHdr, err)
+	pe.TemplateID = tid
+	pe.RecordIndex = record
+	return pe
+}
+
+// readBuffer parses all sets in sl and stores the resulting records in msg.
+// If an error occurs none of the records are stored, unless parsing is
+// lenient. It returns the number of data sets which were skipped because
+// their template is unknown, or could not be parsed. Errors are returned as
+// a *ParseError, with offsets counted from the start of base.
+func (s *Session) readBuffer(base []byte, sl *slice, scope TemplateScope, msg *Message) (int, error) {
+	var recs setRecords
+	recs.Header = msg.Header
+	recs.base = base
+
+	for index := 0; sl.Len() > 0; index++ {
+		recs.setIndex = index
+		start := sl.bytes()
 
-	for sl.Len() > 0 {
 		// Read a set header
 		var setHdr setHeader
 		setHdr.unmarshal(sl)
@@ -264,7 +520,12 @@ func (s *Session) readBuffer(sl *slice) ([]TemplateRecord, []DataRecord, error)
 			if debug {
 				dl.Println("setHdr too short")
 			}
-			return nil, nil, io.ErrUnexpectedEOF
+			err := recs.setError(start, setHdr, io.ErrUnexpectedEOF)
+			if !s.lenient {
+				return 0, err
+			}
+			recs.addError(err)
+			break
 		}
 
 		// Grab the bytes representing the set
@@ -274,39 +535,64 @@ func (s *Session) readBuffer(sl *slice) ([]TemplateRecord, []DataRecord, error)
 			if debug {
 				dl.Println("slice error")
 			}
-			return nil, nil, err
+			pe := recs.setError(start, setHdr, err)
+			if !s.lenient {
+				return 0, pe
+			}
+			recs.addError(pe)
+			break
+		}
+
+		if s.lenient && setHdr.SetID > 3 && setHdr.SetID < 256 {
+			// Reserved, ignored
+			continue
 		}
 
 		// Parse them
-		ts, ds, err = s.readSet(setHdr, setSl)
-		if err != nil {
+		if err := s.readSet(setHdr, setSl, scope, &recs); err != nil {
 			if debug {
 				dl.Println("readSet:", err)
 			}
-			return nil, nil, err
+			if _, ok := err.(*ParseError); !ok {
+				err = recs.setError(start, setHdr, err)
+			}
+			if !s.lenient {
+				return 0, err
+			}
+			recs.addError(err)
 		}
-
-		trecs = append(trecs, ts...)
-		drecs = append(drecs, ds...)
 	}
 
-	return trecs, drecs, nil
+	msg.TemplateRecords = recs.TemplateRecords
+	msg.DataRecords = recs.DataRecords
+	msg.OptionsTemplateRecords = recs.OptionsTemplateRecords
+	msg.OptionsDataRecords = recs.OptionsDataRecords
+	msg.Errors = recs.Errors
+	return recs.unknownSets, nil
 }
 
-func (s *Session) readSet(setHdr setHeader, sl *slice) ([]TemplateRecord, []DataRecord, error) {
-	var trecs []TemplateRecord
-	var drecs []DataRecord
+// addError records the error of a set skipped in lenient mode.
+func (recs *setRecords) addError(err error) {
+	recs.Errors = append(recs.Errors, err)
+	recs.unknownSets++
+}
 
-	minLen := int(s.getMinRecLen(setHdr.SetID))
+// readSet parses the records of a single set and appends them to recs.
+// Errors within a record are returned as a *ParseError.
+func (s *Session) readSet(setHdr setHeader, sl *slice, scope TemplateScope, recs *setRecords) error {
+	minLen := int(s.getMinRecLen(scope, setHdr.SetID))
 
-	for sl.Len() > 0 && sl.Error() == nil {
+	for record := 0; sl.Len() > 0 && sl.Error() == nil; record++ {
 		if sl.Len() < minLen {
 			if debug {
 				dl.Println("ignoring padding")
 			}
 			// Padding
-			return trecs, drecs, sl.Error()
+			return nil
 		}
+		start := sl.bytes()
+		var tid uint16
+		var err error
 
 		// Set ID
 		//
@@ -323,15 +609,34 @@ func (s *Session) readSet(setHdr setHeader, sl *slice) ([]TemplateRecord, []Data
 				dl.Println("parsing NFv9 template set")
 			}
 			tr := s.readTemplateRecord(sl)
+			tr.Scope = scope
+			tid = tr.TemplateID
+			if err = sl.Error(); err != nil {
+				// Do not register a truncated template
+				break
+			}
 			s.registerTemplateRecord(&tr)
-			trecs = append(trecs, tr)
+			recs.TemplateRecords = append(recs.TemplateRecords, tr)
 
 		case setHdr.SetID == 1:
-			// Options Template Set, not handled
+			// Options Template Set
+			if sl.Len() < nfv9OptionsTemplateHeaderLength {
+				if debug {
+					dl.Println("ignoring padding")
+				}
+				return nil
+			}
 			if debug {
-				dl.Println("skipping NFv9 option template set")
+				dl.Println("parsing NFv9 options template set")
 			}
-			sl.Cut(sl.Len())
+			var otr OptionsTemplateRecord
+			if otr, err = s.readNFv9OptionsTemplateRecord(sl); err != nil {
+				break
+			}
+			otr.Scope = scope
+			tid = otr.TemplateID
+			s.registerOptionsTemplateRecord(&otr)
+			recs.OptionsTemplateRecords = append(recs.OptionsTemplateRecords, otr)
 
 		case setHdr.SetID == 2:
 			// Template Set
@@ -339,76 +644,121 @@ func (s *Session) readSet(setHdr setHeader, sl *slice) ([]TemplateRecord, []Data
 				dl.Println("parsing template set")
 			}
 			tr := s.readTemplateRecord(sl)
+			tr.Scope = scope
+			tid = tr.TemplateID
+			if err = sl.Error(); err != nil {
+				// Do not register a truncated template
+				break
+			}
 			s.registerTemplateRecord(&tr)
-			trecs = append(trecs, tr)
+			recs.TemplateRecords = append(recs.TemplateRecords, tr)
 
 		case setHdr.SetID == 3:
-			// Options Template Set, not handled
+			// Options Template Set
+			if sl.Len() < templateHeaderLength {
+				if debug {
+					dl.Println("ignoring padding")
+				}
+				return nil
+			}
 			if debug {
-				dl.Println("skipping option template set")
+				dl.Println("parsing options template set")
+			}
+			var otr OptionsTemplateRecord
+			if otr, err = s.readOptionsTemplateRecord(sl); err != nil {
+				break
 			}
-			sl.Cut(sl.Len())
+			otr.Scope = scope
+			tid = otr.TemplateID
+			s.registerOptionsTemplateRecord(&otr)
+			recs.OptionsTemplateRecords = append(recs.OptionsTemplateRecords, otr)
 
 		case setHdr.SetID > 3 && setHdr.SetID < 256:
 			// Reserved, shouldn't happen
 			if debug {
 				dl.Println("bad SetID", setHdr.SetID)
 			}
-			return nil, nil, ErrProtocol
+			return ErrProtocol
 
 		default:
 			// Data set
 			if debug {
 				dl.Println("parsing data set")
 			}
-			tpl := s.lookupTemplateFieldSpecifiers(setHdr.SetID)
+			tid = setHdr.SetID
+			tpl := s.lookupTemplateFieldSpecifiers(scope, setHdr.SetID)
 
 			if tpl != nil {
 				// Data set
-				ds, err := s.readDataRecord(sl, tpl)
-				if err != nil {
-					return nil, nil, err
+				var ds DataRecord
+				if ds, err = s.readDataRecord(sl, tpl); err != nil {
+					break
+				}
+				ds.TemplateID = s.unaliasTemplateID(scope, setHdr.SetID)
+				ds.Scope = scope
+				if count := s.lookupScopeFieldCount(scope, ds.TemplateID); count > 0 {
+					// Options data set
+					recs.OptionsDataRecords = append(recs.OptionsDataRecords, OptionsDataRecord{
+						TemplateID:  ds.TemplateID,
+						Scope:       scope,
+						ScopeFields: ds.Fields[:count],
+						Fields:      ds.Fields[count:],
+					})
+				} else {
+					recs.DataRecords = append(recs.DataRecords, ds)
 				}
-				ds.TemplateID = s.unaliasTemplateID(setHdr.SetID)
-				drecs = append(drecs, ds)
 			} else {
 				// Data set with unknown template
 				// We can't trust set length, because we might be out of sync.
 				// Consume rest of message.
-				return trecs, drecs, sl.Error()
+				s.addPending(scope, recs.Header, setHdr.SetID, sl)
+				if s.lenient {
+					return recs.recordError(start, setHdr, tid, -1, ErrUnknownTemplate)
+				}
+				recs.unknownSets++
+				return nil
 			}
 		}
+
+		if err == nil {
+			err = sl.Error()
+		}
+		if err != nil {
+			return recs.recordError(start, setHdr, tid, record, err)
+		}
 	}
 
-	return trecs, drecs, sl.Error()
+	return nil
 }
 
-func (s *Session) unaliasTemplateID(tid uint16) uint16 {
+func (s *Session) unaliasTemplateID(scope TemplateScope, tid uint16) uint16 {
 	if s.withIDAliasing {
 		s.mut.RLock()
-		tid = s.aliases[tid]
+		tid = s.aliases[templateKey{scope, tid}]
 		s.mut.RUnlock()
 	}
 	return tid
 }
 
+// readDataRecord reads one record described by tpl. A field which can not be
+// read is reported as a *fieldError.
 func (s *Session) readDataRecord(sl *slice, tpl []TemplateFieldSpecifier) (DataRecord, error) {
 	var dr DataRecord
 	dr.Fields = make([][]byte, len(tpl))
 
-	var err error
 	total := 0
 	for i := range tpl {
+		start := sl.bytes()
 		var val []byte
 		if tpl[i].Length == 65535 {
-			val, err = s.readVariableLength(sl)
-			if err != nil {
-				return DataRecord{}, err
-			}
+			val, _ = s.readVariableLength(sl)
 		} else {
 			l := int(tpl[i].Length)
 			val = sl.Cut(l)
 		}
+		if err := sl.Error(); err != nil {
+			return DataRecord{}, &fieldError{index: i, at: start, err: err}
+		}
 		dr.Fields[i] = val
 		total += len(val)
 	}
@@ -426,7 +776,7 @@ func (s *Session) readDataRecord(sl *slice, tpl []TemplateFieldSpecifier) (DataR
 		next += ln
 	}
 
-	return dr, sl.Error()
+	return dr, nil
 }
 
 func (s *Session) readTemplateRecord(sl *slice) TemplateRecord {
@@ -438,8 +788,14 @@ func (s *Session) readTemplateRecord(sl *slice) TemplateRecord {
 
 	var tr TemplateRecord
 	tr.TemplateID = th.TemplateID
-	tr.FieldSpecifiers = make([]TemplateFieldSpecifier, th.FieldCount)
-	for i := 0; i < int(th.FieldCount); i++ {
+	tr.FieldSpecifiers = s.readFieldSpecifiers(sl, int(th.FieldCount))
+
+	return tr
+}
+
+func (s *Session) readFieldSpecifiers(sl *slice, count int) []TemplateFieldSpecifier {
+	fs := make([]TemplateFieldSpecifier, count)
+	for i := 0; i < count; i++ {
 		f := TemplateFieldSpecifier{}
 		f.FieldID = sl.Uint16()
 		f.Length = sl.Uint16()
@@ -447,42 +803,123 @@ func (s *Session) readTemplateRecord(sl *slice) TemplateRecord {
 			f.FieldID -= 0x8000
 			f.EnterpriseID = sl.Uint32()
 		}
-		tr.FieldSpecifiers[i] = f
+		fs[i] = f
 	}
+	return fs
+}
 
-	return tr
+func (s *Session) readOptionsTemplateRecord(sl *slice) (OptionsTemplateRecord, error) {
+	var th templateHeader
+	th.unmarshal(sl)
+	if debug {
+		dl.Printf("optionsTemplateHeader: %+v", th)
+	}
+
+	var otr OptionsTemplateRecord
+	otr.TemplateID = th.TemplateID
+	if th.FieldCount == 0 {
+		// Withdrawal, there is no scope field count
+		return otr, sl.Error()
+	}
+
+	otr.ScopeFieldCount = sl.Uint16()
+	if otr.ScopeFieldCount == 0 || otr.ScopeFieldCount > th.FieldCount {
+		// The scope field count must not be zero
+		return OptionsTemplateRecord{}, ErrProtocol
+	}
+	otr.FieldSpecifiers = s.readFieldSpecifiers(sl, int(th.FieldCount))
+
+	return otr, sl.Error()
 }
 
+func (s *Session) readNFv9OptionsTemplateRecord(sl *slice) (OptionsTemplateRecord, error) {
+	var th nfv9OptionsTemplateHeader
+	th.unmarshal(sl)
+	if debug {
+		dl.Printf("nfv9OptionsTemplateHeader: %+v", th)
+	}
+
+	if th.ScopeLength%4 != 0 || th.OptionLength%4 != 0 {
+		// Every field is a 2 byte type and 2 byte length
+		return OptionsTemplateRecord{}, ErrProtocol
+	}
+
+	var otr OptionsTemplateRecord
+	otr.TemplateID = th.TemplateID
+	otr.ScopeFieldCount = th.ScopeLength / 4
+	otr.FieldSpecifiers = make([]TemplateFieldSpecifier, (th.ScopeLength+th.OptionLength)/4)
+	for i := range otr.FieldSpecifiers {
+		otr.FieldSpecifiers[i].FieldID = sl.Uint16()
+		otr.FieldSpecifiers[i].Length = sl.Uint16()
+	}
+
+	return otr, sl.Error()
+}
+
+// Template IDs which withdraw all templates or all options templates of a scope
+// when sent with a field count of zero.
+const (
+	withdrawAllTemplatesID        = 2
+	withdrawAllOptionsTemplatesID = 3
+)
+
 func (s *Session) registerTemplateRecord(tr *TemplateRecord) {
+	if tr.TemplateID == withdrawAllTemplatesID && len(tr.FieldSpecifiers) == 0 {
+		s.withdrawAllTemplateRecords(tr.Scope, false)
+		return
+	}
 	if s.withIDAliasing {
-		tr.TemplateID = s.registerAliasedTemplateRecord(*tr)
+		tr.TemplateID = s.registerAliasedTemplateRecord(*tr, 0)
 	} else {
-		s.registerUnaliasedTemplateRecord(*tr)
+		s.registerUnaliasedTemplateRecord(*tr, 0)
 	}
 }
 
-func (s *Session) registerUnaliasedTemplateRecord(tr TemplateRecord) {
+func (s *Session) registerOptionsTemplateRecord(otr *OptionsTemplateRecord) {
+	if otr.TemplateID == withdrawAllOptionsTemplatesID && len(otr.FieldSpecifiers) == 0 {
+		s.withdrawAllTemplateRecords(otr.Scope, true)
+		return
+	}
+	tr := TemplateRecord{
+		TemplateID:      otr.TemplateID,
+		Scope:           otr.Scope,
+		FieldSpecifiers: otr.FieldSpecifiers,
+	}
+	if s.withIDAliasing {
+		otr.TemplateID = s.registerAliasedTemplateRecord(tr, otr.ScopeFieldCount)
+	} else {
+		s.registerUnaliasedTemplateRecord(tr, otr.ScopeFieldCount)
+	}
+}
+
+func (s *Session) registerUnaliasedTemplateRecord(tr TemplateRecord, scopeCount uint16) {
 	// Update templates and minimum record cache
-	tid := tr.TemplateID
+	key := templateKey{tr.Scope, tr.TemplateID}
 	tpl := tr.FieldSpecifiers
 	minLen := calcMinRecLen(tpl)
 	s.mut.Lock()
 	defer s.mut.Unlock()
 	if minLen == 0 {
-		delete(s.specifiers, tid)
+		s.removeTemplate(key)
+		return
+	}
+	s.specifiers[key] = tpl
+	if scopeCount > 0 {
+		s.scopeCounts[key] = scopeCount
 	} else {
-		s.specifiers[tid] = tpl
+		delete(s.scopeCounts, key)
 	}
-	s.minRecord[tid] = minLen
+	s.minRecord[key] = minLen
+	s.refreshed[key] = time.Now()
 }
 
-func (s *Session) registerAliasedTemplateRecord(tr TemplateRecord) uint16 {
+func (s *Session) registerAliasedTemplateRecord(tr TemplateRecord, scopeCount uint16) uint16 {
 	var tid uint16
 	if len(tr.FieldSpecifiers) == 0 {
 		s.withdrawAliasedTemplateRecord(tr)
 		tid = tr.TemplateID
 	} else {
-		tid = s.aliasTemplateRecord(tr)
+		tid = s.aliasTemplateRecord(tr, scopeCount)
 	}
 
 	if debug {
@@ -491,9 +928,13 @@ func (s *Session) registerAliasedTemplateRecord(tr TemplateRecord) uint16 {
 	return tid
 }
 
-func (s *Session) aliasTemplateRecord(tr TemplateRecord) uint16 {
+func (s *Session) aliasTemplateRecord(tr TemplateRecord, scopeCount uint16) uint16 {
 	var buffer bytes.Buffer
 	binary.Write(&buffer, binary.BigEndian, tr.FieldSpecifiers)
+	if scopeCount > 0 {
+		// Keep options templates apart from data templates with the same fields
+		binary.Write(&buffer, binary.BigEndian, scopeCount)
+	}
 	hash := sha1.Sum(buffer.Bytes())
 
 	var ntid uint16
@@ -505,19 +946,24 @@ func (s *Session) aliasTemplateRecord(tr TemplateRecord) uint16 {
 	} else {
 		ntid = s.nextID
 		s.signatures[hash] = ntid
-		s.specifiers[ntid] = tr.FieldSpecifiers
+		s.specifiers[templateKey{id: ntid}] = tr.FieldSpecifiers
+		if scopeCount > 0 {
+			s.scopeCounts[templateKey{id: ntid}] = scopeCount
+		}
 		s.nextID++
 
 		if s.nextID == 65535 {
 			panic("IPFIX has run out of virtual template ids!")
 		}
 
-		s.minRecord[ntid] = calcMinRecLen(tr.FieldSpecifiers)
+		s.minRecord[templateKey{id: ntid}] = calcMinRecLen(tr.FieldSpecifiers)
 	}
 
-	if _, ok := s.aliases[tr.TemplateID]; !ok {
-		s.aliases[tr.TemplateID] = ntid
-	}
+	// A template ID may be redefined by the exporter at any time, so always
+	// point it at the latest definition.
+	key := templateKey{tr.Scope, tr.TemplateID}
+	s.aliases[key] = ntid
+	s.refreshed[key] = time.Now()
 
 	return ntid
 }
@@ -525,7 +971,125 @@ func (s *Session) aliasTemplateRecord(tr TemplateRecord) uint16 {
 func (s *Session) withdrawAliasedTemplateRecord(tr TemplateRecord) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
-	delete(s.aliases, tr.TemplateID)
+	s.removeTemplate(templateKey{tr.Scope, tr.TemplateID})
+}
+
+// withdrawAllTemplateRecords removes either all templates or all options
+// templates of the given scope.
+func (s *Session) withdrawAllTemplateRecords(scope TemplateScope, options bool) {
+	if debug {
+		dl.Printf("Withdrawing all templates of %+v (options: %v)", scope, options)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for key := range s.refreshed {
+		if key.scope != scope {
+			continue
+		}
+		_, isOptions := s.scopeCounts[s.storedKey(key)]
+		if isOptions == options {
+			s.removeTemplate(key)
+		}
+	}
+}
+
+// ExpireTemplates removes all templates which have not been refreshed within
+// the timeout set by WithTemplateTimeout, as of now. It returns the number of
+// templates removed. ExpireTemplates should be called periodically by
+// collectors using an unreliable transport; it does nothing if no timeout has
+// been configured.
+func (s *Session) ExpireTemplates(now time.Time) int {
+	if s.templateTimeout <= 0 {
+		return 0
+	}
+	deadline := now.Add(-s.templateTimeout)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	var n int
+	for key, t := range s.refreshed {
+		if t.Before(deadline) {
+			if debug {
+				dl.Printf("Expiring template %d of %+v", key.id, key.scope)
+			}
+			s.removeTemplate(key)
+			n++
+		}
+	}
+	return n
+}
+
+// ForgetExporter removes the templates, sequence state and pending data sets
+// of all observation domains of the exporter at addr, as passed to
+// ParseBufferFrom. Templates received over a connection oriented transport
+// are only valid for the lifetime of the connection, so collectors call
+// ForgetExporter when the connection closes.
+func (s *Session) ForgetExporter(addr net.Addr) {
+	if addr == nil {
+		return
+	}
+	exporter := addr.String()
+	if debug {
+		dl.Printf("Forgetting exporter %s", exporter)
+	}
+
+	s.mut.Lock()
+	for key := range s.refreshed {
+		if key.scope.Exporter == exporter {
+			s.removeTemplate(key)
+		}
+	}
+	s.mut.Unlock()
+
+	s.seqMut.Lock()
+	for scope := range s.sequences {
+		if scope.Exporter == exporter {
+			delete(s.sequences, scope)
+		}
+	}
+	s.seqMut.Unlock()
+
+	s.pendMut.Lock()
+	for scope := range s.pending {
+		if scope.Exporter == exporter {
+			delete(s.pending, scope)
+		}
+	}
+	s.pendMut.Unlock()
+}
+
+// TemplateRefreshed returns the time the given template was last received
+// from the exporter (or loaded into the session), and whether the template is
+// currently known. The template ID is the one used by the exporter.
+func (s *Session) TemplateRefreshed(scope TemplateScope, tid uint16) (time.Time, bool) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	t, ok := s.refreshed[templateKey{scope, tid}]
+	return t, ok
+}
+
+// storedKey returns the key the template identified by the exporter's scope
+// and template ID is stored under. The caller must hold the lock.
+func (s *Session) storedKey(key templateKey) templateKey {
+	if s.withIDAliasing {
+		return templateKey{id: s.aliases[key]}
+	}
+	return key
+}
+
+// removeTemplate forgets the template identified by the exporter's scope and
+// template ID. Aliased templates are shared between scopes so only the alias
+// is removed. The caller must hold the lock.
+func (s *Session) removeTemplate(key templateKey) {
+	if s.withIDAliasing {
+		delete(s.aliases, key)
+	} else {
+		delete(s.specifiers, key)
+		delete(s.scopeCounts, key)
+		delete(s.minRecord, key)
+	}
+	delete(s.refreshed, key)
 }
 
 func calcMinRecLen(tpl []TemplateFieldSpecifier) uint16 {
@@ -548,65 +1112,91 @@ dataLoop:
 	for _, dr := range m.DataRecords {
 		// First walk and make sure this template isn't already in the return list
 		for _, t := range tr {
-			if t.TemplateID == dr.TemplateID {
+			if t.TemplateID == dr.TemplateID && t.Scope == dr.Scope {
 				continue dataLoop
 			}
 		}
 		// If we got this far, we haven't seen the template ID yet
-		tfs := s.lookupTemplateFieldSpecifiers(dr.TemplateID)
+		tfs := s.lookupUnaliasedTemplateFieldSpecifiers(dr.Scope, dr.TemplateID)
 		if len(tfs) == 0 {
 			return nil, ErrUnknownTemplate
 		}
-		tr = append(tr, TemplateRecord{TemplateID: dr.TemplateID, FieldSpecifiers: tfs})
+		tr = append(tr, TemplateRecord{TemplateID: dr.TemplateID, Scope: dr.Scope, FieldSpecifiers: tfs})
 	}
 	return tr, nil
 }
 
-func (s *Session) lookupTemplateFieldSpecifiers(tid uint16) []TemplateFieldSpecifier {
+// lookupTemplateFieldSpecifiers looks up a template by the ID used by the
+// exporter.
+func (s *Session) lookupTemplateFieldSpecifiers(scope TemplateScope, tid uint16) []TemplateFieldSpecifier {
 	var tpl []TemplateFieldSpecifier
 
 	if s.withIDAliasing {
-		tpl = s.lookupAliasedTemplateFieldSpecifiers(tid)
+		tpl = s.lookupAliasedTemplateFieldSpecifiers(scope, tid)
 	} else {
-		tpl = s.lookupUnaliasedTemplateFieldSpecifiers(tid)
+		tpl = s.lookupUnaliasedTemplateFieldSpecifiers(scope, tid)
 	}
 
 	return tpl
 }
 
-func (s *Session) lookupUnaliasedTemplateFieldSpecifiers(tid uint16) []TemplateFieldSpecifier {
+// lookupUnaliasedTemplateFieldSpecifiers looks up a template by the ID found
+// in parsed records, which is the alias if ID aliasing is enabled.
+func (s *Session) lookupUnaliasedTemplateFieldSpecifiers(scope TemplateScope, tid uint16) []TemplateFieldSpecifier {
 	var tpl []TemplateFieldSpecifier
 
+	if tpl, ok := fixedTemplates[tid]; ok {
+		return tpl
+	}
+
 	s.mut.RLock()
 	defer s.mut.RUnlock()
-	if id, ok := s.specifiers[tid]; ok {
+	if id, ok := s.specifiers[s.unaliasedKey(scope, tid)]; ok {
 		tpl = id
 	}
 
 	return tpl
 }
 
-func (s *Session) lookupAliasedTemplateFieldSpecifiers(tid uint16) []TemplateFieldSpecifier {
+func (s *Session) lookupAliasedTemplateFieldSpecifiers(scope TemplateScope, tid uint16) []TemplateFieldSpecifier {
 	var tpl []TemplateFieldSpecifier
 
 	s.mut.RLock()
-	if id, ok := s.aliases[tid]; ok {
-		tpl = s.specifiers[id]
+	if id, ok := s.aliases[templateKey{scope, tid}]; ok {
+		tpl = s.specifiers[templateKey{id: id}]
 	}
 	s.mut.RUnlock()
 
 	return tpl
 }
 
-func (s *Session) getMinRecLen(tid uint16) uint16 {
+// unaliasedKey returns the key a template is stored under, given the ID found
+// in parsed records.
+func (s *Session) unaliasedKey(scope TemplateScope, tid uint16) templateKey {
+	if s.withIDAliasing {
+		return templateKey{id: tid}
+	}
+	return templateKey{scope, tid}
+}
+
+// lookupScopeFieldCount returns the number of scope fields of the given
+// template, or zero if it is not an options template. Template IDs are
+// expected to be unaliased already.
+func (s *Session) lookupScopeFieldCount(scope TemplateScope, tid uint16) uint16 {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.scopeCounts[s.unaliasedKey(scope, tid)]
+}
+
+func (s *Session) getMinRecLen(scope TemplateScope, tid uint16) uint16 {
 	var minLen uint16
 
 	s.mut.RLock()
 	defer s.mut.RUnlock()
 	if s.withIDAliasing {
-		minLen = s.minRecord[s.aliases[tid]]
+		minLen = s.minRecord[templateKey{id: s.aliases[templateKey{scope, tid}]}]
 	} else {
-		minLen = s.minRecord[tid]
+		minLen = s.minRecord[templateKey{scope, tid}]
 	}
 
 	return minLen
@@ -625,24 +1215,36 @@ func (s *Session) readVariableLength(sl *slice) (val []byte, err error) {
 	return sl.Cut(l), sl.Error()
 }
 
+// ExportTemplateRecords returns the templates known to the session along with
+// their scope, so they can be restored with LoadTemplateRecords.
 func (s *Session) ExportTemplateRecords() []TemplateRecord {
-	trecs := make([]TemplateRecord, 0, len(s.specifiers))
 	s.mut.RLock()
 	defer s.mut.RUnlock()
+	trecs := make([]TemplateRecord, 0, len(s.specifiers))
 
 	if s.withIDAliasing {
-		for t, a := range s.aliases {
+		for k, a := range s.aliases {
+			if _, ok := s.scopeCounts[templateKey{id: a}]; ok {
+				// Options template, see ExportOptionsTemplateRecords
+				continue
+			}
 			tr := TemplateRecord{
-				TemplateID:      t,
-				FieldSpecifiers: s.specifiers[a],
+				TemplateID:      k.id,
+				Scope:           k.scope,
+				FieldSpecifiers: s.specifiers[templateKey{id: a}],
 			}
 
 			trecs = append(trecs, tr)
 		}
 	} else {
-		for t, fs := range s.specifiers {
+		for k, fs := range s.specifiers {
+			if _, ok := s.scopeCounts[k]; ok {
+				// Options template, see ExportOptionsTemplateRecords
+				continue
+			}
 			tr := TemplateRecord{
-				TemplateID:      t,
+				TemplateID:      k.id,
+				Scope:           k.scope,
 				FieldSpecifiers: fs,
 			}
 			trecs = append(trecs, tr)
@@ -652,93 +1254,90 @@ func (s *Session) ExportTemplateRecords() []TemplateRecord {
 	return trecs
 }
 
+// LoadTemplateRecords registers templates in the scope given by each record.
 func (s *Session) LoadTemplateRecords(trecs []TemplateRecord) {
+	scopes := make([]TemplateScope, 0, len(trecs))
 	for _, tr := range trecs {
 		s.registerTemplateRecord(&tr)
+		scopes = append(scopes, tr.Scope)
 	}
+	s.replayScopes(scopes)
 }
 
-// Returns overall length of the message, the length of the template set, and
-// the length of the data set(s).
-func (s *Session) calculateMarshalledLength(m Message) (int, int, int, error) {
-	var length int
-	var tmplLen, dataLen int
-	if m.Header.Version == 0x0a {
-		length += msgIpfixHeaderLength // there will always be a header
-	} else {
-		length += msgNFv9HeaderLength // there will always be a header
-	}
-	if len(m.TemplateRecords) > 0 {
-		// We will be creating a template set
-		tmplLen += setHeaderLength
-		for _, rec := range m.TemplateRecords {
-			// Each template record implies a record header
-			tmplLen += 4
-			for _, field := range rec.FieldSpecifiers {
-				if field.EnterpriseID == 0 {
-					tmplLen += 4
-				} else {
-					tmplLen += 8
-				}
+// ExportOptionsTemplateRecords returns the options templates known to the
+// session, so they can be restored with LoadOptionsTemplateRecords.
+func (s *Session) ExportOptionsTemplateRecords() []OptionsTemplateRecord {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	otrecs := make([]OptionsTemplateRecord, 0, len(s.scopeCounts))
+
+	if s.withIDAliasing {
+		for k, a := range s.aliases {
+			if count, ok := s.scopeCounts[templateKey{id: a}]; ok {
+				otrecs = append(otrecs, OptionsTemplateRecord{
+					TemplateID:      k.id,
+					Scope:           k.scope,
+					ScopeFieldCount: count,
+					FieldSpecifiers: s.specifiers[templateKey{id: a}],
+				})
 			}
 		}
-	}
-	if len(m.DataRecords) > 0 {
-		currentTemplate := m.DataRecords[0].TemplateID
-		tpl := s.lookupUnaliasedTemplateFieldSpecifiers(currentTemplate)
-		if len(tpl) == 0 {
-			return 0, 0, 0, ErrUnknownTemplate
-		}
-		dataLen += setHeaderLength
-		for _, dr := range m.DataRecords {
-			if dr.TemplateID != currentTemplate {
-				dataLen += setHeaderLength
-				if tpl = s.lookupUnaliasedTemplateFieldSpecifiers(dr.TemplateID); len(tpl) == 0 {
-					return 0, 0, 0, ErrUnknownTemplate
-				}
-				currentTemplate = dr.TemplateID
-			}
-			for i, field := range dr.Fields {
-				if i >= len(tpl) {
-					return 0, 0, 0, errors.New("too many fields for template")
-				}
-				// Handle variable-length fields
-				if tpl[i].Length == 0xffff {
-					if len(field) < 0xff {
-						dataLen += 1          // 1 byte for the length
-						dataLen += len(field) // and then the field itself
-					} else {
-						dataLen += 3          // 3 bytes for the length
-						dataLen += len(field) // and then the field itself
-					}
-				} else {
-					dataLen += int(tpl[i].Length)
-				}
-			}
+	} else {
+		for k, count := range s.scopeCounts {
+			otrecs = append(otrecs, OptionsTemplateRecord{
+				TemplateID:      k.id,
+				Scope:           k.scope,
+				ScopeFieldCount: count,
+				FieldSpecifiers: s.specifiers[k],
+			})
 		}
 	}
-	length += tmplLen
-	length += dataLen
-	return length, tmplLen, dataLen, nil
+
+	return otrecs
+}
+
+// LoadOptionsTemplateRecords registers options templates previously
+// returned by ExportOptionsTemplateRecords.
+func (s *Session) LoadOptionsTemplateRecords(otrecs []OptionsTemplateRecord) {
+	scopes := make([]TemplateScope, 0, len(otrecs))
+	for _, otr := range otrecs {
+		s.registerOptionsTemplateRecord(&otr)
+		scopes = append(scopes, otr.Scope)
+	}
+	s.replayScopes(scopes)
 }
 
 // Marshall a Message struct back into a raw IPFIX buffer
 func (s *Session) Marshal(m Message) ([]byte, error) {
+	if isFixedFormat(m.Header.Version) {
+		return m.marshalFixedFormat()
+	}
+
+	//do not look to aliases for field specifiers during marshal, they are unaliased when parsed
+	return m.marshal(s.lookupUnaliasedTemplateFieldSpecifiers)
+}
+
+// marshal marshals an IPFIX or NFv9 message, looking up the templates of the
+// data records with lu.
+func (m Message) marshal(lu lookupFunc) ([]byte, error) {
 	// First we'll calculate how big the message will be
-	length, tmplLen, _, err := s.calculateMarshalledLength(m)
+	length, tmplLen, optTmplLen, err := m.calculateMarshalledLength(lu)
 	if err != nil {
 		return []byte{}, err
 	}
+	if length > maxMessageLength {
+		return []byte{}, ErrMessageTooLong
+	}
 
 	// Now make the empty buffer (brings us down to 1 allocation per call to Marshal)
 	message := make([]byte, length)
 
-	marshalHeader(m.Header, uint16(length), uint16(len(m.TemplateRecords)+len(m.DataRecords)), message)
+	marshalHeader(m.Header, uint16(length), uint16(m.recordCount()), message)
 
 	offset := m.marshalTemplates(tmplLen, message)
+	offset = m.marshalOptionsTemplates(offset, optTmplLen, message)
 
-	//do not look to aliases for field specifiers during marshal, they are unaliased when parsed
-	if err = m.marshalRecords(offset, s.lookupUnaliasedTemplateFieldSpecifiers, message); err != nil {
+	if err = m.marshalRecords(offset, lu, message); err != nil {
 		return []byte{}, err
 	}
 	return message, nil
@@ -748,23 +1347,33 @@ func (s *Session) Marshal(m Message) ([]byte, error) {
 // the Message must have a populated Template header for EVERY Record header
 // if we can't identify a corresponding template for each record, we return an error
 func (m Message) Marshal() ([]byte, error) {
-	length, tmplLen, _, err := m.calculateMarshalledLength()
-	if err != nil {
-		return nil, err
+	if isFixedFormat(m.Header.Version) {
+		return m.marshalFixedFormat()
 	}
-	message := make([]byte, length)
-	marshalHeader(m.Header, uint16(length), uint16(len(m.TemplateRecords)+len(m.DataRecords)), message)
+	return m.marshal(m.lookupScopedTemplateFieldSpecifiers)
+}
 
-	offset := m.marshalTemplates(tmplLen, message)
-	if offset > len(message) {
-		return nil, ErrRead
-	}
+// recordCount returns the total number of template, options template, data
+// and options data records in the message.
+func (m Message) recordCount() int {
+	return len(m.TemplateRecords) + len(m.OptionsTemplateRecords) + len(m.DataRecords) + len(m.OptionsDataRecords)
+}
 
-	if err = m.marshalRecords(offset, m.lookupTemplateFieldSpecifiers, message); err != nil {
-		return []byte{}, nil
+// dataRecords returns the data records of the message followed by the options
+// data records, with the scope and option fields of the latter concatenated.
+func (m Message) dataRecords() []DataRecord {
+	if len(m.OptionsDataRecords) == 0 {
+		return m.DataRecords
 	}
-
-	return message, nil
+	drecs := make([]DataRecord, 0, len(m.DataRecords)+len(m.OptionsDataRecords))
+	drecs = append(drecs, m.DataRecords...)
+	for _, odr := range m.OptionsDataRecords {
+		fields := make([][]byte, 0, len(odr.ScopeFields)+len(odr.Fields))
+		fields = append(fields, odr.ScopeFields...)
+		fields = append(fields, odr.Fields...)
+		drecs = append(drecs, DataRecord{TemplateID: odr.TemplateID, Scope: odr.Scope, Fields: fields})
+	}
+	return drecs
 }
 
 func (m Message) marshalTemplates(tmplLen int, message []byte) (offset int) {
@@ -790,34 +1399,84 @@ func (m Message) marshalTemplates(tmplLen int, message []byte) (offset int) {
 			binary.BigEndian.PutUint16(message[offset+2:offset+4], uint16(len(rec.FieldSpecifiers)))
 			offset += 4
 			// Now build out the fields
+			offset = marshalFieldSpecifiers(offset, rec.FieldSpecifiers, message)
+		}
+	}
+	return
+
+}
+
+func (m Message) marshalOptionsTemplates(offset, optTmplLen int, message []byte) int {
+	if len(m.OptionsTemplateRecords) == 0 {
+		return offset
+	}
+
+	// construct options template set header
+	if m.Header.Version == 0x0a {
+		binary.BigEndian.PutUint16(message[offset:offset+2], 3) // type is always 3 for options templates on IPFIX
+	} else {
+		binary.BigEndian.PutUint16(message[offset:offset+2], 1) // type is always 1 for options templates on NFv9
+	}
+	binary.BigEndian.PutUint16(message[offset+2:offset+4], uint16(optTmplLen))
+	offset += 4
+
+	for _, rec := range m.OptionsTemplateRecords {
+		binary.BigEndian.PutUint16(message[offset:offset+2], rec.TemplateID)
+		if m.Header.Version == 0x0a {
+			// template ID + field count + scope field count, unless withdrawn
+			binary.BigEndian.PutUint16(message[offset+2:offset+4], uint16(len(rec.FieldSpecifiers)))
+			offset += 4
+			if len(rec.FieldSpecifiers) > 0 {
+				binary.BigEndian.PutUint16(message[offset:offset+2], rec.ScopeFieldCount)
+				offset += 2
+			}
+			offset = marshalFieldSpecifiers(offset, rec.FieldSpecifiers, message)
+		} else {
+			// template ID + scope length + option length, in bytes
+			binary.BigEndian.PutUint16(message[offset+2:offset+4], uint16(len(rec.ScopeFieldSpecifiers())*4))
+			binary.BigEndian.PutUint16(message[offset+4:offset+6], uint16(len(rec.OptionFieldSpecifiers())*4))
+			offset += 6
 			for _, field := range rec.FieldSpecifiers {
-				if field.EnterpriseID == 0 {
-					// No enterprise needed
-					binary.BigEndian.PutUint16(message[offset:offset+2], field.FieldID)
-					binary.BigEndian.PutUint16(message[offset+2:offset+4], field.Length)
-					offset += 4
-				} else {
-					binary.BigEndian.PutUint16(message[offset:offset+2], field.FieldID+0x8000)
-					binary.BigEndian.PutUint16(message[offset+2:offset+4], field.Length)
-					binary.BigEndian.PutUint32(message[offset+4:offset+8], field.EnterpriseID)
-					offset += 8
-				}
+				binary.BigEndian.PutUint16(message[offset:offset+2], field.FieldID)
+				binary.BigEndian.PutUint16(message[offset+2:offset+4], field.Length)
+				offset += 4
 			}
 		}
 	}
-	return
 
+	// NFv9 options template sets are padded to a 4 byte boundary, the
+	// buffer is already zeroed so just skip over the padding.
+	return offset + optionsTemplatePadding(m.Header.Version, m.OptionsTemplateRecords)
 }
 
-type lookupFunc func(uint16) []TemplateFieldSpecifier
+func marshalFieldSpecifiers(offset int, fs []TemplateFieldSpecifier, message []byte) int {
+	for _, field := range fs {
+		if field.EnterpriseID == 0 {
+			// No enterprise needed
+			binary.BigEndian.PutUint16(message[offset:offset+2], field.FieldID)
+			binary.BigEndian.PutUint16(message[offset+2:offset+4], field.Length)
+			offset += 4
+		} else {
+			binary.BigEndian.PutUint16(message[offset:offset+2], field.FieldID+0x8000)
+			binary.BigEndian.PutUint16(message[offset+2:offset+4], field.Length)
+			binary.BigEndian.PutUint32(message[offset+4:offset+8], field.EnterpriseID)
+			offset += 8
+		}
+	}
+	return offset
+}
+
+type lookupFunc func(TemplateScope, uint16) []TemplateFieldSpecifier
 
 func (m Message) marshalRecords(offset int, lu lookupFunc, message []byte) (err error) {
 	// Build data record section
 	// It's possible that there were multiple sets with alternating templates,
 	// e.g. set 0 used template 256, set 1 used template 300, set 2 used 256 again.
-	if len(m.DataRecords) > 0 {
-		currentTemplate := m.DataRecords[0].TemplateID
-		tpl := lu(currentTemplate)
+	drecs := m.dataRecords()
+	if len(drecs) > 0 {
+		currentTemplate := drecs[0].TemplateID
+		currentScope := drecs[0].Scope
+		tpl := lu(currentScope, currentTemplate)
 		if tpl == nil {
 			err = ErrUnknownTemplate
 			return
@@ -827,8 +1486,8 @@ func (m Message) marshalRecords(offset int, lu lookupFunc, message []byte) (err
 		// We only write the template ID now, since we won't be sure of length until later
 		binary.BigEndian.PutUint16(message[offset:offset+2], currentTemplate)
 		offset += 4
-		for _, dr := range m.DataRecords {
-			if dr.TemplateID != currentTemplate {
+		for _, dr := range drecs {
+			if dr.TemplateID != currentTemplate || dr.Scope != currentScope {
 				// We've transitioned templates, make a new set
 				if offset-setStart > setHeaderLength {
 					binary.BigEndian.PutUint16(message[setStart+2:setStart+4], uint16(offset-setStart))
@@ -836,7 +1495,8 @@ func (m Message) marshalRecords(offset int, lu lookupFunc, message []byte) (err
 				// now set up for the next set
 				setStart = offset
 				currentTemplate = dr.TemplateID
-				if tpl = lu(dr.TemplateID); tpl == nil {
+				currentScope = dr.Scope
+				if tpl = lu(dr.Scope, dr.TemplateID); tpl == nil {
 					err = ErrUnknownTemplate
 					return
 				}
@@ -910,11 +1570,24 @@ func marshalHeader(hdr MessageHeader, length, totalRecords uint16, buff []byte)
 
 }
 
-// Returns overall length of the message, the length of the template set, and
-// the length of the data set(s).
-func (m Message) calculateMarshalledLength() (int, int, int, error) {
+// optionsTemplatePadding returns the number of padding bytes needed after an
+// options template set. Only NFv9 requires padding.
+func optionsTemplatePadding(version uint16, otrecs []OptionsTemplateRecord) int {
+	if version == 0x0a {
+		return 0
+	}
+	l := 0
+	for _, rec := range otrecs {
+		l += nfv9OptionsTemplateHeaderLength + 4*len(rec.FieldSpecifiers)
+	}
+	return (4 - l%4) % 4
+}
+
+// Returns overall length of the message, the length of the template set, the
+// length of the options template set and the length of the data set(s).
+func (m Message) calculateMarshalledLength(lu lookupFunc) (int, int, int, error) {
 	var length int
-	var tmplLen, dataLen int
+	var tmplLen, optTmplLen, dataLen int
 	if m.Header.Version == 0x0a {
 		length += msgIpfixHeaderLength // there will always be a header
 	} else {
@@ -926,52 +1599,91 @@ func (m Message) calculateMarshalledLength() (int, int, int, error) {
 		for _, rec := range m.TemplateRecords {
 			// Each template record implies a record header
 			tmplLen += 4
-			for _, field := range rec.FieldSpecifiers {
-				if field.EnterpriseID == 0 {
-					tmplLen += 4
-				} else {
-					tmplLen += 8
+			tmplLen += fieldSpecifiersLength(rec.FieldSpecifiers)
+		}
+	}
+	if len(m.OptionsTemplateRecords) > 0 {
+		// We will be creating an options template set
+		optTmplLen += setHeaderLength
+		for _, rec := range m.OptionsTemplateRecords {
+			if m.Header.Version == 0x0a {
+				// template ID + field count, and scope field count unless withdrawn
+				optTmplLen += 4
+				if len(rec.FieldSpecifiers) > 0 {
+					optTmplLen += 2
 				}
+				optTmplLen += fieldSpecifiersLength(rec.FieldSpecifiers)
+			} else {
+				// NFv9 has no enterprise fields
+				optTmplLen += nfv9OptionsTemplateHeaderLength + 4*len(rec.FieldSpecifiers)
 			}
 		}
+		optTmplLen += optionsTemplatePadding(m.Header.Version, m.OptionsTemplateRecords)
 	}
-	if len(m.DataRecords) > 0 {
-		currentTemplate := m.DataRecords[0].TemplateID
-		tpl := m.lookupTemplateFieldSpecifiers(currentTemplate)
-		if tpl == nil {
+	if drecs := m.dataRecords(); len(drecs) > 0 {
+		currentTemplate := drecs[0].TemplateID
+		currentScope := drecs[0].Scope
+		tpl := lu(currentScope, currentTemplate)
+		if len(tpl) == 0 {
 			return 0, 0, 0, ErrUnknownTemplate
 		}
 		dataLen += setHeaderLength
-		for _, dr := range m.DataRecords {
-			if dr.TemplateID != currentTemplate {
+		for _, dr := range drecs {
+			if dr.TemplateID != currentTemplate || dr.Scope != currentScope {
 				dataLen += setHeaderLength
-				if tpl = m.lookupTemplateFieldSpecifiers(dr.TemplateID); tpl == nil {
+				if tpl = lu(dr.Scope, dr.TemplateID); len(tpl) == 0 {
 					return 0, 0, 0, ErrUnknownTemplate
 				}
 				currentTemplate = dr.TemplateID
+				currentScope = dr.Scope
 			}
-			for i, field := range dr.Fields {
-				if i > len(tpl) {
-					return 0, 0, 0, ErrTooManyTemplates
-				}
-				// Handle variable-length fields
-				if tpl[i].Length == 0xffff {
-					if len(field) < 0xff {
-						dataLen += 1          // 1 byte for the length
-						dataLen += len(field) // and then the field itself
-					} else {
-						dataLen += 3          // 3 bytes for the length
-						dataLen += len(field) // and then the field itself
-					}
-				} else {
-					dataLen += int(tpl[i].Length)
-				}
+			n, err := marshalledRecordLength(tpl, dr.Fields)
+			if err != nil {
+				return 0, 0, 0, err
 			}
+			dataLen += n
 		}
 	}
 	length += tmplLen
+	length += optTmplLen
 	length += dataLen
-	return length, tmplLen, dataLen, nil
+	return length, tmplLen, optTmplLen, nil
+}
+
+// marshalledRecordLength returns the length of a data record with the given
+// fields when marshalled with the template.
+func marshalledRecordLength(tpl []TemplateFieldSpecifier, fields [][]byte) (int, error) {
+	var n int
+	for i, field := range fields {
+		if i >= len(tpl) {
+			return 0, ErrTooManyTemplates
+		}
+		// Handle variable-length fields
+		if tpl[i].Length == 0xffff {
+			if len(field) < 0xff {
+				n += 1          // 1 byte for the length
+				n += len(field) // and then the field itself
+			} else {
+				n += 3          // 3 bytes for the length
+				n += len(field) // and then the field itself
+			}
+		} else {
+			n += int(tpl[i].Length)
+		}
+	}
+	return n, nil
+}
+
+func fieldSpecifiersLength(fs []TemplateFieldSpecifier) int {
+	var l int
+	for _, field := range fs {
+		if field.EnterpriseID == 0 {
+			l += 4
+		} else {
+			l += 8
+		}
+	}
+	return l
 }
 
 func (m Message) lookupTemplateFieldSpecifiers(tid uint16) []TemplateFieldSpecifier {
@@ -980,5 +1692,16 @@ func (m Message) lookupTemplateFieldSpecifiers(tid uint16) []TemplateFieldSpecif
 			return v.FieldSpecifiers
 		}
 	}
+	for _, v := range m.OptionsTemplateRecords {
+		if v.TemplateID == tid {
+			return v.FieldSpecifiers
+		}
+	}
 	return nil
 }
+
+// lookupScopedTemplateFieldSpecifiers ignores the scope, a stand alone message
+// only ever refers to its own templates.
+func (m Message) lookupScopedTemplateFieldSpecifiers(_ TemplateScope, tid uint16) []TemplateFieldSpecifier {
+	return m.lookupTemplateFieldSpecifiers(tid)
+}
@@ -7,23 +7,17 @@ import (
 	"bytes"
 	"io"
 	"os"
-
-	"gopkg.in/gcfg.v1"
 )
 
 var extra []DictionaryEntry
 
 func init() {
 	if dictFile := os.Getenv("IPFIXDICT"); dictFile != "" {
-		var dict UserDictionary
-		err := gcfg.ReadFileInto(&dict, dictFile)
+		var err error
+		extra, err = ReadDictionaryFile(dictFile)
 		if err != nil {
 			panic(err)
 		}
-
-		for name, field := range dict.Field {
-			extra = append(extra, field.DictionaryEntry(name))
-		}
 	}
 }
 
@@ -32,9 +26,7 @@ func Fuzz(bs []byte) int {
 
 	s := NewSession()
 	i := NewInterpreter(s)
-	for _, e := range extra {
-		i.AddDictionaryEntry(e)
-	}
+	i.AddDictionaryEntries(extra)
 
 	msg, err := s.ParseReader(r)
 	for err == nil {
@@ -49,22 +41,3 @@ func Fuzz(bs []byte) int {
 
 	return 0
 }
-
-type UserDictionary struct {
-	Field map[string]*Field
-}
-
-type Field struct {
-	ID         uint16
-	Enterprise uint32
-	Type       string
-}
-
-func (f Field) DictionaryEntry(name string) DictionaryEntry {
-	return DictionaryEntry{
-		Name:         name,
-		EnterpriseID: f.Enterprise,
-		FieldID:      f.ID,
-		Type:         FieldTypes[f.Type],
-	}
-}
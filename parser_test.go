@@ -3,9 +3,12 @@ package ipfix
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"io"
+	"net"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestCanCreateSession(t *testing.T) {
@@ -501,3 +504,297 @@ func TestLookupTemplateRecords(t *testing.T) {
 		t.Fatalf("LookupTemplateRecords returned %d records instead of expected 1", len(trs))
 	}
 }
+
+func TestParseOptionsTemplateSet(t *testing.T) {
+	packet, _ := hex.DecodeString("000a003c5685b3700000000000bc614e000300160100000300010095000400220004002300010100001600000001000000640100000002000003e802")
+	p := NewSession()
+
+	msg, err := p.ParseBuffer(packet)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+
+	if len(msg.TemplateRecords) != 0 || len(msg.DataRecords) != 0 {
+		t.Error("Unexpected template or data records", len(msg.TemplateRecords), len(msg.DataRecords))
+	}
+	if len(msg.OptionsTemplateRecords) != 1 {
+		t.Fatal("Incorrect number of options template records", len(msg.OptionsTemplateRecords))
+	}
+	otr := msg.OptionsTemplateRecords[0]
+	if otr.TemplateID != 256 || otr.ScopeFieldCount != 1 || len(otr.FieldSpecifiers) != 3 {
+		t.Errorf("Incorrect options template %+v", otr)
+	}
+	if fs := otr.ScopeFieldSpecifiers(); len(fs) != 1 || fs[0].FieldID != 149 {
+		t.Errorf("Incorrect scope fields %+v", fs)
+	}
+	if len(msg.OptionsDataRecords) != 2 {
+		t.Fatal("Incorrect number of options data records", len(msg.OptionsDataRecords))
+	}
+	odr := msg.OptionsDataRecords[1]
+	if len(odr.ScopeFields) != 1 || !bytes.Equal(odr.ScopeFields[0], []byte{0, 0, 0, 2}) {
+		t.Errorf("Incorrect scope fields %v", odr.ScopeFields)
+	}
+	if len(odr.Fields) != 2 || !bytes.Equal(odr.Fields[1], []byte{2}) {
+		t.Errorf("Incorrect option fields %v", odr.Fields)
+	}
+
+	if otrs := p.ExportOptionsTemplateRecords(); len(otrs) != 1 || otrs[0].ScopeFieldCount != 1 {
+		t.Errorf("Incorrect exported options templates %+v", otrs)
+	}
+	if trs := p.ExportTemplateRecords(); len(trs) != 0 {
+		t.Errorf("Options templates exported as templates %+v", trs)
+	}
+
+	marshalled, err := p.Marshal(msg)
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	if !bytes.Equal(marshalled, packet) {
+		t.Fatalf("Expected didn't match marshalled bytes: %x", marshalled)
+	}
+	marshalled, err = msg.Marshal()
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	if !bytes.Equal(marshalled, packet) {
+		t.Fatalf("Expected didn't match marshalled bytes: %x", marshalled)
+	}
+}
+
+func TestParseV9OptionsTemplateSet(t *testing.T) {
+	packet, _ := hex.DecodeString("00090002198afac45defcbd800103e570000000000010018010100040008000100040022000400230004000001010010000000000000006400000001")
+	p := NewSession(WithIDAliasing(true))
+
+	msg, err := p.ParseBuffer(packet)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+
+	if len(msg.OptionsTemplateRecords) != 1 {
+		t.Fatal("Incorrect number of options template records", len(msg.OptionsTemplateRecords))
+	}
+	otr := msg.OptionsTemplateRecords[0]
+	if otr.ScopeFieldCount != 1 || len(otr.FieldSpecifiers) != 3 {
+		t.Errorf("Incorrect options template %+v", otr)
+	}
+	if len(msg.OptionsDataRecords) != 1 {
+		t.Fatal("Incorrect number of options data records", len(msg.OptionsDataRecords))
+	}
+	odr := msg.OptionsDataRecords[0]
+	if odr.TemplateID != otr.TemplateID || len(odr.ScopeFields) != 1 || len(odr.Fields) != 2 {
+		t.Errorf("Incorrect options data record %+v", odr)
+	}
+
+	// Aliasing changes the template ID, so restore it before comparing
+	msg.OptionsTemplateRecords[0].TemplateID = 257
+	msg.OptionsDataRecords[0].TemplateID = 257
+	marshalled, err := msg.Marshal()
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	if !bytes.Equal(marshalled, packet) {
+		t.Fatalf("Expected didn't match marshalled bytes: %x", marshalled)
+	}
+}
+
+func TestParseBufferFromScopesTemplates(t *testing.T) {
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	pB, _ := hex.DecodeString("000a002c5685b3700000000000000001000200100100000200080004000c00040100000c0a0000010a000002")
+	dataA, _ := hex.DecodeString("000a00205685b370000000000000000101000010c0a800c9c0a8000100000001")
+	addrA := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 2055}
+	addrB := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 2055}
+
+	for _, aliasing := range []bool{false, true} {
+		p := NewSession(WithIDAliasing(aliasing))
+		if _, err := p.ParseBufferFrom(pA, addrA); err != nil {
+			t.Fatal("ParseBufferFrom failed", err)
+		}
+		if _, err := p.ParseBufferFrom(pB, addrB); err != nil {
+			t.Fatal("ParseBufferFrom failed", err)
+		}
+
+		msg, err := p.ParseBufferFrom(dataA, addrA)
+		if err != nil {
+			t.Fatal("ParseBufferFrom failed", err)
+		}
+		if len(msg.DataRecords) != 1 || len(msg.DataRecords[0].Fields) != 3 {
+			t.Fatalf("Template of exporter A was overwritten by exporter B: %+v", msg.DataRecords)
+		}
+		if scope := msg.DataRecords[0].Scope; scope.Exporter != addrA.String() || scope.DomainID != 1 {
+			t.Errorf("Incorrect scope %+v", scope)
+		}
+		if fl := NewInterpreter(p).Interpret(msg.DataRecords[0]); len(fl) != 3 {
+			t.Errorf("Interpreter used the wrong template: %+v", fl)
+		}
+
+		// Unknown to the session without the exporter address
+		if msg, err = p.ParseBuffer(dataA); err != nil || len(msg.DataRecords) != 0 {
+			t.Errorf("Unexpected data records %+v (%v)", msg.DataRecords, err)
+		}
+
+		trecs := p.ExportTemplateRecords()
+		if len(trecs) != 2 {
+			t.Fatal("Incorrect number of exported templates", len(trecs))
+		}
+		p2 := NewSession(WithIDAliasing(aliasing))
+		p2.LoadTemplateRecords(trecs)
+		msg, err = p2.ParseBufferFrom(dataA, addrA)
+		if err != nil {
+			t.Fatal("ParseBufferFrom failed", err)
+		}
+		if len(msg.DataRecords) != 1 || len(msg.DataRecords[0].Fields) != 3 {
+			t.Fatalf("Loaded templates lost their scope: %+v", msg.DataRecords)
+		}
+	}
+}
+
+func TestParseBufferScopesTemplatesByDomain(t *testing.T) {
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	pB, _ := hex.DecodeString("000a002c5685b3700000000000000002000200100100000200080004000c00040100000c0a0000010a000002")
+	dataA, _ := hex.DecodeString("000a00205685b370000000000000000101000010c0a800c9c0a8000100000001")
+	p := NewSession()
+
+	if _, err := p.ParseBuffer(pA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if _, err := p.ParseBuffer(pB); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	msg, err := p.ParseBuffer(dataA)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(msg.DataRecords) != 1 || len(msg.DataRecords[0].Fields) != 3 {
+		t.Fatalf("Template of domain 1 was overwritten by domain 2: %+v", msg.DataRecords)
+	}
+}
+
+func TestWithdrawAllTemplates(t *testing.T) {
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	pOpts, _ := hex.DecodeString("000a00265685b370000000000000000100030016010100030001009500040022000400230001")
+	withdrawAll, _ := hex.DecodeString("000a00185685b37000000000000000010002000800020000")
+	withdrawAllOpts, _ := hex.DecodeString("000a00185685b37000000000000000010003000800030000")
+
+	for _, aliasing := range []bool{false, true} {
+		p := NewSession(WithIDAliasing(aliasing))
+		for _, packet := range [][]byte{pA, pOpts, withdrawAllOpts} {
+			if _, err := p.ParseBuffer(packet); err != nil {
+				t.Fatal("ParseBuffer failed", err)
+			}
+		}
+		if otrs := p.ExportOptionsTemplateRecords(); len(otrs) != 0 {
+			t.Errorf("Options templates were not withdrawn: %+v", otrs)
+		}
+		if trs := p.ExportTemplateRecords(); len(trs) != 1 {
+			t.Errorf("Templates were withdrawn along with options templates: %+v", trs)
+		}
+
+		if _, err := p.ParseBuffer(withdrawAll); err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if trs := p.ExportTemplateRecords(); len(trs) != 0 {
+			t.Errorf("Templates were not withdrawn: %+v", trs)
+		}
+		if _, ok := p.TemplateRefreshed(TemplateScope{DomainID: 1}, 256); ok {
+			t.Error("Withdrawn template still has a refresh time")
+		}
+	}
+}
+
+func TestExpireTemplates(t *testing.T) {
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	dataA, _ := hex.DecodeString("000a00205685b370000000000000000101000010c0a800c9c0a8000100000001")
+
+	for _, aliasing := range []bool{false, true} {
+		p := NewSession(WithIDAliasing(aliasing), WithTemplateTimeout(time.Minute))
+		if _, err := p.ParseBuffer(pA); err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+
+		refreshed, ok := p.TemplateRefreshed(TemplateScope{DomainID: 1}, 256)
+		if !ok || time.Since(refreshed) > time.Minute {
+			t.Fatalf("Incorrect refresh time %v (%v)", refreshed, ok)
+		}
+
+		if n := p.ExpireTemplates(time.Now()); n != 0 {
+			t.Errorf("Expired %d fresh templates", n)
+		}
+		if msg, err := p.ParseBuffer(dataA); err != nil || len(msg.DataRecords) != 1 {
+			t.Fatalf("Unexpected data records %+v (%v)", msg.DataRecords, err)
+		}
+
+		if n := p.ExpireTemplates(time.Now().Add(2 * time.Minute)); n != 1 {
+			t.Errorf("Expired %d templates instead of 1", n)
+		}
+		if msg, err := p.ParseBuffer(dataA); err != nil || len(msg.DataRecords) != 0 {
+			t.Errorf("Unexpected data records %+v (%v)", msg.DataRecords, err)
+		}
+	}
+
+	p := NewSession()
+	p.ParseBuffer(pA)
+	if n := p.ExpireTemplates(time.Now().Add(time.Hour)); n != 0 {
+		t.Errorf("Expired %d templates without a timeout", n)
+	}
+}
+
+func TestLenientParsing(t *testing.T) {
+	// Template 256 with one data record, a set with reserved ID 5, a data set
+	// for the unknown template 300, an options template set with a zero scope
+	// field count and another data record for template 256.
+	packet, _ := hex.DecodeString("000a005e5685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a80001000000010005000800000000012c0008000000000003000a01010001000001000010c0a80002c0a8000100000002")
+
+	p := NewSession()
+	if _, err := p.ParseBuffer(packet); !errors.Is(err, ErrProtocol) {
+		t.Error("Expected ErrProtocol in strict mode, got", err)
+	}
+
+	p = NewSession(WithLenientParsing(true))
+	msg, err := p.ParseBuffer(packet)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(msg.TemplateRecords) != 1 {
+		t.Error("Incorrect number of template records", len(msg.TemplateRecords))
+	}
+	if len(msg.DataRecords) != 2 {
+		t.Error("Incorrect number of data records", len(msg.DataRecords))
+	}
+	if len(msg.Errors) != 2 {
+		t.Fatal("Incorrect number of errors", msg.Errors)
+	}
+	if !errors.Is(msg.Errors[0], ErrUnknownTemplate) {
+		t.Error("Expected ErrUnknownTemplate, got", msg.Errors[0])
+	}
+	if !errors.Is(msg.Errors[1], ErrProtocol) {
+		t.Error("Expected ErrProtocol, got", msg.Errors[1])
+	}
+
+	// A set running past the end of the message stops parsing, but the
+	// records parsed so far are returned
+	truncated := append(packet[:len(packet):len(packet)], 0x01, 0x00, 0x00, 0x20, 0xc0, 0xa8)
+	msg, err = p.ParseBuffer(truncated)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(msg.DataRecords) != 2 {
+		t.Error("Incorrect number of data records", len(msg.DataRecords))
+	}
+	if len(msg.Errors) != 3 || !errors.Is(msg.Errors[2], ErrRead) {
+		t.Error("Incorrect errors", msg.Errors)
+	}
+
+	// A template truncated by the end of its set is not registered
+	truncated, _ = hex.DecodeString("000a00205685b3700000000000000001000200100100000300080004000c0004")
+	p = NewSession(WithLenientParsing(true))
+	msg, err = p.ParseBuffer(truncated)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(msg.TemplateRecords) != 0 || len(msg.Errors) != 1 || !errors.Is(msg.Errors[0], ErrRead) {
+		t.Error("Incorrect truncated template", msg.TemplateRecords, msg.Errors)
+	}
+	if tpl := p.lookupTemplateFieldSpecifiers(TemplateScope{DomainID: 1}, 256); tpl != nil {
+		t.Error("Truncated template registered", tpl)
+	}
+}
@@ -0,0 +1,233 @@
+package ipfix
+
+import (
+	"time"
+)
+
+// This implements the pending data cache. Exporters only send templates
+// periodically, so after a collector restart data sets may arrive before the
+// template describing them. Instead of dropping such sets, they can be held
+// back until the template arrives and then be replayed.
+
+// PendingCallback is the type of function set by WithPendingCallback. The
+// message holds the records of one replayed data set, along with the header
+// of the message the set originally arrived in.
+type PendingCallback func(Message)
+
+// WithPendingDataSets enables the pending data cache. Data sets referring to
+// an unknown template are retained, up to maxBytes of set data per exporter
+// and observation domain, for at most maxAge (or indefinitely if maxAge is
+// zero). When the template arrives the sets are decoded and delivered to the
+// PendingCallback, or kept for DrainPending if no callback has been set, up
+// to maxBytes of set data in total. When the cache is full the oldest sets
+// are dropped first.
+func WithPendingDataSets(maxBytes int, maxAge time.Duration) Option {
+	return func(s *Session) {
+		s.pendingMaxBytes = maxBytes
+		s.pendingMaxAge = maxAge
+	}
+}
+
+// WithPendingCallback sets the function replayed data sets are delivered to,
+// see WithPendingDataSets. The callback is called synchronously from the
+// parsing functions, once the message carrying the templates has been
+// parsed, and from LoadTemplateRecords and LoadOptionsTemplateRecords once
+// all templates have been loaded.
+func WithPendingCallback(cb PendingCallback) Option {
+	return func(s *Session) {
+		s.pendingCallback = cb
+	}
+}
+
+type pendingSet struct {
+	header   MessageHeader
+	setID    uint16
+	data     []byte
+	received time.Time
+}
+
+// A replayedSet is a replayed data set kept for DrainPending, with the
+// length of its set data.
+type replayedSet struct {
+	msg   Message
+	bytes int
+}
+
+type pendingQueue struct {
+	sets  []pendingSet
+	bytes int
+}
+
+// expire drops sets received before the deadline, and the oldest sets until
+// at most maxBytes of set data remain.
+func (q *pendingQueue) expire(deadline time.Time, maxBytes int) {
+	i := 0
+	for ; i < len(q.sets); i++ {
+		if q.bytes <= maxBytes && !q.sets[i].received.Before(deadline) {
+			break
+		}
+		if debug {
+			dl.Printf("dropping pending set %d", q.sets[i].setID)
+		}
+		q.bytes -= len(q.sets[i].data)
+	}
+	q.sets = q.sets[i:]
+}
+
+// addPending retains the remainder of a data set with an unknown template,
+// if the pending data cache is enabled.
+func (s *Session) addPending(scope TemplateScope, hdr MessageHeader, setID uint16, sl *slice) {
+	if s.pendingMaxBytes <= 0 || sl.Len() > s.pendingMaxBytes {
+		return
+	}
+	data := make([]byte, sl.Len())
+	copy(data, sl.Cut(sl.Len()))
+	now := time.Now()
+
+	s.pendMut.Lock()
+	defer s.pendMut.Unlock()
+	q, ok := s.pending[scope]
+	if !ok {
+		q = &pendingQueue{}
+		s.pending[scope] = q
+	}
+	q.sets = append(q.sets, pendingSet{header: hdr, setID: setID, data: data, received: now})
+	q.bytes += len(data)
+	q.expire(s.pendingDeadline(now), s.pendingMaxBytes)
+}
+
+// pendingDeadline returns the time before which pending sets are dropped.
+func (s *Session) pendingDeadline(now time.Time) time.Time {
+	if s.pendingMaxAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-s.pendingMaxAge)
+}
+
+// replayParsed replays the pending sets of the scope of a parsed message, if
+// the message registered templates. Templates may also have been registered
+// before a parse error.
+func (s *Session) replayParsed(scope TemplateScope, msg Message, err error) {
+	if err != nil || len(msg.TemplateRecords) > 0 || len(msg.OptionsTemplateRecords) > 0 {
+		s.replayPending(scope)
+	}
+}
+
+// replayScopes replays the pending sets of each of the scopes once.
+func (s *Session) replayScopes(scopes []TemplateScope) {
+	done := make(map[TemplateScope]bool, len(scopes))
+	for _, scope := range scopes {
+		if !done[scope] {
+			done[scope] = true
+			s.replayPending(scope)
+		}
+	}
+}
+
+// replayPending decodes and delivers the pending sets of the scope whose
+// templates have become known. It is called once a message carrying
+// templates has been parsed, or templates have been loaded, rather than for
+// each template, so that the callback is not called in the middle of a
+// parse.
+func (s *Session) replayPending(scope TemplateScope) {
+	if s.pendingMaxBytes <= 0 {
+		return
+	}
+
+	var ready []pendingSet
+	s.pendMut.Lock()
+	if q, ok := s.pending[scope]; ok {
+		q.expire(s.pendingDeadline(time.Now()), s.pendingMaxBytes)
+		i := 0
+		for _, ps := range q.sets {
+			if s.lookupTemplateFieldSpecifiers(scope, ps.setID) != nil {
+				ready = append(ready, ps)
+				q.bytes -= len(ps.data)
+			} else {
+				q.sets[i] = ps
+				i++
+			}
+		}
+		q.sets = q.sets[:i]
+		if len(q.sets) == 0 {
+			delete(s.pending, scope)
+		}
+	}
+	s.pendMut.Unlock()
+
+	var replayed []replayedSet
+	for _, ps := range ready {
+		var recs setRecords
+		recs.Header = ps.header
+		recs.base = ps.data
+		recs.setIndex = -1
+		setHdr := setHeader{SetID: ps.setID, Length: uint16(setHeaderLength + len(ps.data))}
+		if err := s.readSet(setHdr, newSlice(ps.data), scope, &recs); err != nil {
+			if debug {
+				dl.Println("replaying pending set:", err)
+			}
+			continue
+		}
+		replayed = append(replayed, replayedSet{
+			msg: Message{
+				Header:             ps.header,
+				DataRecords:        recs.DataRecords,
+				OptionsDataRecords: recs.OptionsDataRecords,
+			},
+			bytes: len(ps.data),
+		})
+	}
+	if len(replayed) == 0 {
+		return
+	}
+
+	if s.pendingCallback != nil {
+		for _, rs := range replayed {
+			s.pendingCallback(rs.msg)
+		}
+		return
+	}
+	s.pendMut.Lock()
+	s.replayed = append(s.replayed, replayed...)
+	for _, rs := range replayed {
+		s.replayedBytes += rs.bytes
+	}
+	i := 0
+	for ; s.replayedBytes > s.pendingMaxBytes; i++ {
+		if debug {
+			dl.Printf("dropping replayed set %d", s.replayed[i].msg.Header.SequenceNumber)
+		}
+		s.replayedBytes -= s.replayed[i].bytes
+	}
+	s.replayed = s.replayed[i:]
+	s.pendMut.Unlock()
+}
+
+// DrainPending returns the replayed data sets which have not been delivered
+// to a PendingCallback, see WithPendingDataSets. Each message holds the
+// records of one data set. DrainPending should be called regularly when no
+// callback is set: the replayed sets are kept until then, but only up to the
+// maxBytes of WithPendingDataSets, beyond which the oldest are dropped.
+func (s *Session) DrainPending() []Message {
+	s.pendMut.Lock()
+	defer s.pendMut.Unlock()
+	var msgs []Message
+	for _, rs := range s.replayed {
+		msgs = append(msgs, rs.msg)
+	}
+	s.replayed = nil
+	s.replayedBytes = 0
+	return msgs
+}
+
+// PendingDataSets returns the number of data sets currently waiting for their
+// template.
+func (s *Session) PendingDataSets() int {
+	s.pendMut.Lock()
+	defer s.pendMut.Unlock()
+	var n int
+	for _, q := range s.pending {
+		n += len(q.sets)
+	}
+	return n
+}
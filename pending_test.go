@@ -0,0 +1,215 @@
+package ipfix
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPendingDataSets(t *testing.T) {
+	// Template 256 with one data record, and a data set for template 256
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	dataA, _ := hex.DecodeString("000a00205685b370000000070000000101000010c0a800c9c0a8000100000001")
+
+	p := NewSession(WithPendingDataSets(1<<16, time.Minute))
+	msg, err := p.ParseBuffer(dataA)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(msg.DataRecords) != 0 {
+		t.Error("Incorrect number of data records", len(msg.DataRecords))
+	}
+	if n := p.PendingDataSets(); n != 1 {
+		t.Fatal("Incorrect number of pending sets", n)
+	}
+
+	msg, err = p.ParseBuffer(pA)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if n := p.PendingDataSets(); n != 0 {
+		t.Error("Incorrect number of pending sets", n)
+	}
+
+	replayed := p.DrainPending()
+	if len(replayed) != 1 {
+		t.Fatal("Incorrect number of replayed sets", len(replayed))
+	}
+	if replayed[0].Header.SequenceNumber != 7 {
+		t.Error("Incorrect header of replayed set", replayed[0].Header)
+	}
+	if len(replayed[0].DataRecords) != 1 {
+		t.Fatal("Incorrect number of replayed records", len(replayed[0].DataRecords))
+	}
+	dr := replayed[0].DataRecords[0]
+	if dr.TemplateID != 256 || dr.Scope != (TemplateScope{DomainID: 1}) {
+		t.Errorf("Incorrect replayed record %+v", dr)
+	}
+	for i, f := range dr.Fields {
+		if !bytes.Equal(f, msg.DataRecords[0].Fields[i]) {
+			t.Errorf("Incorrect field %d: %x != %x", i, f, msg.DataRecords[0].Fields[i])
+		}
+	}
+	if len(p.DrainPending()) != 0 {
+		t.Error("DrainPending returned sets twice")
+	}
+}
+
+func TestPendingLoadedTemplates(t *testing.T) {
+	dataA, _ := hex.DecodeString("000a00205685b370000000070000000101000010c0a800c9c0a8000100000001")
+
+	p := NewSession(WithPendingDataSets(1<<16, time.Minute))
+	if _, err := p.ParseBuffer(dataA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if n := p.PendingDataSets(); n != 1 {
+		t.Fatal("Incorrect number of pending sets", n)
+	}
+
+	// Restoring a template cache releases the set
+	p.LoadTemplateRecords([]TemplateRecord{{
+		TemplateID:      256,
+		Scope:           TemplateScope{DomainID: 1},
+		FieldSpecifiers: []TemplateFieldSpecifier{{FieldID: 8, Length: 4}, {FieldID: 12, Length: 4}, {FieldID: 2, Length: 4}},
+	}})
+	if n := p.PendingDataSets(); n != 0 {
+		t.Error("Incorrect number of pending sets", n)
+	}
+	replayed := p.DrainPending()
+	if len(replayed) != 1 || len(replayed[0].DataRecords) != 1 {
+		t.Fatal("Incorrect replayed sets", replayed)
+	}
+	if f := replayed[0].DataRecords[0].Fields[2]; !bytes.Equal(f, []byte{0, 0, 0, 1}) {
+		t.Errorf("Incorrect replayed field %x", f)
+	}
+
+	// And so do options templates
+	p = NewSession(WithPendingDataSets(1<<16, time.Minute))
+	if _, err := p.ParseBuffer(dataA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	p.LoadOptionsTemplateRecords([]OptionsTemplateRecord{{
+		TemplateID:      256,
+		Scope:           TemplateScope{DomainID: 1},
+		ScopeFieldCount: 1,
+		FieldSpecifiers: []TemplateFieldSpecifier{{FieldID: 8, Length: 4}, {FieldID: 12, Length: 4}, {FieldID: 2, Length: 4}},
+	}})
+	if replayed := p.DrainPending(); len(replayed) != 1 || len(replayed[0].OptionsDataRecords) != 1 {
+		t.Error("Incorrect replayed options sets", replayed)
+	}
+}
+
+func TestPendingCallback(t *testing.T) {
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	dataA, _ := hex.DecodeString("000a00205685b370000000070000000101000010c0a800c9c0a8000100000001")
+	addrA := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 4739}
+	addrB := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 2), Port: 4739}
+
+	var replayed []Message
+	p := NewSession(WithPendingDataSets(1<<16, 0), WithPendingCallback(func(msg Message) {
+		replayed = append(replayed, msg)
+	}))
+	if _, err := p.ParseBufferFrom(dataA, addrA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+
+	// A template from another exporter does not release the set
+	if _, err := p.ParseBufferFrom(pA, addrB); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(replayed) != 0 {
+		t.Fatal("Incorrect number of replayed sets", len(replayed))
+	}
+
+	if _, err := p.ParseBufferFrom(pA, addrA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(replayed) != 1 || len(replayed[0].DataRecords) != 1 {
+		t.Fatalf("Incorrect replayed sets %+v", replayed)
+	}
+	if replayed[0].DataRecords[0].Scope.Exporter != addrA.String() {
+		t.Error("Incorrect scope", replayed[0].DataRecords[0].Scope)
+	}
+	if len(p.DrainPending()) != 0 {
+		t.Error("DrainPending returned sets delivered to the callback")
+	}
+}
+
+func TestPendingReplayAfterParse(t *testing.T) {
+	// Templates 256 and 257 in one template set, and a data set for 256
+	pAB, _ := hex.DecodeString("000a002c5685b37000000000000000010002001c0100000300080004000c0004000200040101000100080004")
+	dataA, _ := hex.DecodeString("000a00205685b370000000070000000101000010c0a800c9c0a8000100000001")
+
+	var p *Session
+	var replayed int
+	p = NewSession(WithPendingDataSets(1<<16, 0), WithPendingCallback(func(msg Message) {
+		replayed++
+		if p.lookupTemplateFieldSpecifiers(TemplateScope{DomainID: 1}, 257) == nil {
+			t.Error("Pending set replayed before the message was parsed")
+		}
+	}))
+	if _, err := p.ParseBuffer(dataA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if _, err := p.ParseBuffer(pAB); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if replayed != 1 {
+		t.Error("Incorrect number of replayed sets", replayed)
+	}
+}
+
+func TestPendingLimits(t *testing.T) {
+	dataA, _ := hex.DecodeString("000a00205685b370000000070000000101000010c0a800c9c0a8000100000001")
+
+	// Disabled by default
+	p := NewSession()
+	if _, err := p.ParseBuffer(dataA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if n := p.PendingDataSets(); n != 0 {
+		t.Error("Incorrect number of pending sets", n)
+	}
+
+	// Room for a single set of 12 bytes
+	p = NewSession(WithPendingDataSets(20, 0))
+	for i := 0; i < 3; i++ {
+		if _, err := p.ParseBuffer(dataA); err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+	}
+	if n := p.PendingDataSets(); n != 1 {
+		t.Error("Incorrect number of pending sets", n)
+	}
+
+	// Replayed sets waiting for DrainPending are limited the same way, over
+	// all exporters
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	for i := byte(1); i <= 3; i++ {
+		addr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, i), Port: 4739}
+		if _, err := p.ParseBufferFrom(dataA, addr); err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if _, err := p.ParseBufferFrom(pA, addr); err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+	}
+	replayed := p.DrainPending()
+	if len(replayed) != 1 || replayed[0].DataRecords[0].Scope.Exporter != "192.0.2.3:4739" {
+		t.Error("Incorrect replayed sets", replayed)
+	}
+
+	p = NewSession(WithPendingDataSets(1<<16, time.Millisecond))
+	if _, err := p.ParseBuffer(dataA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := p.ParseBuffer(dataA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if n := p.PendingDataSets(); n != 1 {
+		t.Error("Incorrect number of pending sets", n)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le
+// +build linux,!mips,!mipsle,!mips64,!mips64le
+
+package ipfix
+
+// The syscall package predates SO_REUSEPORT on Linux.
+const soReusePort = 0xf
@@ -0,0 +1,8 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le)
+// +build linux
+// +build mips mipsle mips64 mips64le
+
+package ipfix
+
+// The syscall package predates SO_REUSEPORT on Linux.
+const soReusePort = 0x200
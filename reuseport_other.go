@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package ipfix
+
+import "syscall"
+
+// setReusePort fails, SO_REUSEPORT is not available on this platform.
+func setReusePort(network, address string, c syscall.RawConn) error {
+	return ErrReusePort
+}
@@ -0,0 +1,197 @@
+package ipfix
+
+// This implements tracking of message sequence numbers, which lets a
+// collector detect flow data lost on unreliable transports such as UDP.
+//
+// IPFIX (RFC 7011 section 3.1) counts the data records sent from an
+// observation domain before the current message. Netflow v9 (RFC 3954
+// section 5.1) counts the export packets sent by the exporter. Netflow v5
+// and v7 count the flow records, like IPFIX, and Netflow v1 has no sequence
+// numbers.
+
+// Sequence numbers further than this behind the expected value are taken to
+// mean that the exporter restarted, rather than that messages were reordered.
+const (
+	ipfixReorderWindow = 1 << 16 // data records
+	nfv9ReorderWindow  = 1 << 8  // export packets
+)
+
+// Netflow v9 exporters are also taken to have restarted when their boot time,
+// as derived from the export time and the system uptime, moves by more than
+// this many milliseconds. The export time only has a resolution of a second.
+const nfv9BootTimeSlack = 5000
+
+// SequenceEventType describes a detected irregularity in the sequence
+// numbers of an exporter.
+type SequenceEventType int
+
+const (
+	// SequenceLoss means that records (IPFIX, Netflow v5 and v7) or export
+	// packets (Netflow v9) were skipped.
+	SequenceLoss SequenceEventType = iota + 1
+	// SequenceDuplicate means that the previous message was received again.
+	SequenceDuplicate
+	// SequenceOutOfOrder means that a message arrived after later messages
+	// had already been received.
+	SequenceOutOfOrder
+	// SequenceRestart means that the exporter reset its sequence numbers,
+	// usually because it was restarted.
+	SequenceRestart
+)
+
+func (t SequenceEventType) String() string {
+	switch t {
+	case SequenceLoss:
+		return "loss"
+	case SequenceDuplicate:
+		return "duplicate"
+	case SequenceOutOfOrder:
+		return "out of order"
+	case SequenceRestart:
+		return "restart"
+	default:
+		return "unknown"
+	}
+}
+
+// A SequenceEvent is passed to the SequenceCallback when an irregularity is
+// detected. Lost is the number of records (IPFIX) or export packets
+// (Netflow v9) skipped, and is only set for SequenceLoss events.
+type SequenceEvent struct {
+	Type     SequenceEventType
+	Scope    TemplateScope
+	Version  uint16
+	Expected uint32
+	Received uint32
+	Lost     uint32
+}
+
+// SequenceCallback is the type of function set by WithSequenceCallback.
+type SequenceCallback func(SequenceEvent)
+
+// SequenceStats holds the sequence number counters of a single exporter and
+// observation domain. Lost is counted in data records for IPFIX and in export
+// packets for Netflow v9; records which were counted as lost but later arrive
+// out of order are subtracted again.
+type SequenceStats struct {
+	Messages   uint64
+	Lost       uint64
+	Duplicates uint64
+	OutOfOrder uint64
+	Restarts   uint64
+}
+
+type sequenceState struct {
+	version  uint16
+	synced   bool // expected is valid
+	expected uint32
+	last     uint32
+	boot     int64 // Netflow v9 only, in milliseconds
+	stats    SequenceStats
+}
+
+// SequenceStats returns the sequence number counters of every exporter and
+// observation domain seen by the session.
+func (s *Session) SequenceStats() map[TemplateScope]SequenceStats {
+	s.seqMut.Lock()
+	defer s.seqMut.Unlock()
+	stats := make(map[TemplateScope]SequenceStats, len(s.sequences))
+	for scope, st := range s.sequences {
+		stats[scope] = st.stats
+	}
+	return stats
+}
+
+// trackSequence checks the sequence number of a successfully parsed message
+// against the expected value. If complete is false some data sets could not
+// be decoded, so the number of records in the message is unknown and the next
+// expected IPFIX sequence number can not be predicted.
+func (s *Session) trackSequence(scope TemplateScope, msg Message, complete bool) {
+	hdr := msg.Header
+	var n, window uint32
+	switch hdr.Version {
+	case ipfixVersion, netflowV5Version, netflowV7Version:
+		n = uint32(len(msg.DataRecords) + len(msg.OptionsDataRecords))
+		window = ipfixReorderWindow
+	case nfv9Version:
+		n = 1
+		window = nfv9ReorderWindow
+		complete = true
+	default:
+		return
+	}
+
+	s.seqMut.Lock()
+	st, ok := s.sequences[scope]
+	if !ok || st.version != hdr.Version {
+		st = &sequenceState{version: hdr.Version}
+		s.sequences[scope] = st
+	}
+	st.stats.Messages++
+
+	var boot int64
+	if hdr.Version == nfv9Version {
+		boot = int64(hdr.ExportTime)*1000 - int64(hdr.SysUptime)
+	}
+
+	ev := SequenceEvent{
+		Scope:    scope,
+		Version:  hdr.Version,
+		Expected: st.expected,
+		Received: hdr.SequenceNumber,
+	}
+	advance := true
+
+	switch diff := int32(hdr.SequenceNumber - st.expected); {
+	case !st.synced:
+		// First message, or the previous message could not be counted
+	case boot-st.boot > nfv9BootTimeSlack || st.boot-boot > nfv9BootTimeSlack:
+		ev.Type = SequenceRestart
+	case diff == 0:
+		// In order
+	case diff > 0:
+		ev.Type = SequenceLoss
+		ev.Lost = uint32(diff)
+		st.stats.Lost += uint64(diff)
+	case hdr.SequenceNumber == st.last:
+		ev.Type = SequenceDuplicate
+		advance = false
+	case uint32(-diff) <= window:
+		ev.Type = SequenceOutOfOrder
+		advance = false
+		// These were counted as lost when the gap was seen
+		if uint64(n) < st.stats.Lost {
+			st.stats.Lost -= uint64(n)
+		} else {
+			st.stats.Lost = 0
+		}
+	default:
+		ev.Type = SequenceRestart
+	}
+
+	switch ev.Type {
+	case SequenceDuplicate:
+		st.stats.Duplicates++
+	case SequenceOutOfOrder:
+		st.stats.OutOfOrder++
+	case SequenceRestart:
+		st.stats.Restarts++
+	}
+
+	if advance {
+		st.expected = hdr.SequenceNumber + n
+		st.last = hdr.SequenceNumber
+		st.boot = boot
+		st.synced = complete
+	}
+	s.seqMut.Unlock()
+
+	if ev.Type != 0 {
+		if debug {
+			dl.Printf("sequence %v: %+v", ev.Type, ev)
+		}
+		if s.sequenceCallback != nil {
+			s.sequenceCallback(ev)
+		}
+	}
+}
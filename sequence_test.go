@@ -0,0 +1,102 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+func TestIPFIXSequenceTracking(t *testing.T) {
+	pA, _ := hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	dataA, _ := hex.DecodeString("000a00205685b370000000000000000101000010c0a800c9c0a8000100000001")
+
+	var events []SequenceEvent
+	p := NewSession(WithSequenceCallback(func(ev SequenceEvent) {
+		events = append(events, ev)
+	}))
+	if _, err := p.ParseBuffer(pA); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+
+	tests := []struct {
+		seq  uint32
+		want SequenceEventType
+	}{
+		{1, 0},
+		{5, SequenceLoss},
+		{5, SequenceDuplicate},
+		{3, SequenceOutOfOrder},
+		{6, 0},
+		{0xffff0000, SequenceRestart},
+		{0xffff0001, 0},
+	}
+	for _, tc := range tests {
+		events = events[:0]
+		binary.BigEndian.PutUint32(dataA[8:], tc.seq)
+		if _, err := p.ParseBuffer(dataA); err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if tc.want == 0 {
+			if len(events) != 0 {
+				t.Errorf("Sequence %d: unexpected events %+v", tc.seq, events)
+			}
+			continue
+		}
+		if len(events) != 1 || events[0].Type != tc.want {
+			t.Errorf("Sequence %d: got events %+v, want %v", tc.seq, events, tc.want)
+		}
+	}
+
+	stats := p.SequenceStats()[TemplateScope{DomainID: 1}]
+	want := SequenceStats{Messages: 8, Lost: 2, Duplicates: 1, OutOfOrder: 1, Restarts: 1}
+	if stats != want {
+		t.Errorf("Incorrect stats %+v, want %+v", stats, want)
+	}
+}
+
+func TestNFv9SequenceTracking(t *testing.T) {
+	packet, _ := hex.DecodeString("00090000198afac45defcbd80000000000000001")
+
+	var events []SequenceEvent
+	p := NewSession(WithSequenceCallback(func(ev SequenceEvent) {
+		events = append(events, ev)
+	}))
+
+	tests := []struct {
+		seq    uint32
+		uptime uint32
+		want   SequenceEventType
+	}{
+		{10, 0x198afac4, 0},
+		{11, 0x198afac4, 0},
+		{13, 0x198afac4, SequenceLoss},
+		{12, 0x198afac0, SequenceOutOfOrder},
+		{13, 0x198afac4, SequenceDuplicate},
+		{14, 0x198afac8, 0},
+		{0, 1000, SequenceRestart},
+		{1, 1000, 0},
+	}
+	for _, tc := range tests {
+		events = events[:0]
+		binary.BigEndian.PutUint32(packet[4:], tc.uptime)
+		binary.BigEndian.PutUint32(packet[12:], tc.seq)
+		if _, err := p.ParseBuffer(packet); err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if tc.want == 0 {
+			if len(events) != 0 {
+				t.Errorf("Sequence %d: unexpected events %+v", tc.seq, events)
+			}
+			continue
+		}
+		if len(events) != 1 || events[0].Type != tc.want {
+			t.Errorf("Sequence %d: got events %+v, want %v", tc.seq, events, tc.want)
+		}
+	}
+
+	stats := p.SequenceStats()[TemplateScope{DomainID: 1}]
+	want := SequenceStats{Messages: 8, Lost: 0, Duplicates: 1, OutOfOrder: 1, Restarts: 1}
+	if stats != want {
+		t.Errorf("Incorrect stats %+v, want %+v", stats, want)
+	}
+}
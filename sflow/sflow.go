@@ -0,0 +1,592 @@
+// Package sflow decodes sFlow version 5 datagrams into IPFIX shaped messages.
+//
+// Every flow sample and counter sample in a datagram becomes one
+// ipfix.DataRecord, whose fields are IPFIX information elements such as
+// sourceIPv4Address, samplingInterval or octetTotalCount. The templates
+// describing the records are registered with the Decoder's ipfix.Session, so
+// the records can be handled by an ipfix.Interpreter, an ipfix.Filter or
+// Session.Marshal like any parsed IPFIX record.
+//
+// The following sFlow structures are decoded, everything else is skipped:
+//
+//	flow sample, expanded flow sample
+//	    raw packet header (Ethernet, IPv4 and IPv6 headers are decoded too)
+//	    Ethernet frame data, IPv4 data, IPv6 data
+//	    extended switch data, extended router data
+//	counter sample, expanded counter sample
+//	    generic interface counters
+package sflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/gravwell/ipfix"
+)
+
+const sflowVersion = 5
+
+// ErrTemplateSpace is returned when a Decoder has run out of template IDs
+// for the different record layouts it has seen.
+var ErrTemplateSpace = errors.New("sflow: out of template IDs")
+
+// Sample formats, enterprise 0
+const (
+	formatFlowSample            = 1
+	formatCounterSample         = 2
+	formatExpandedFlowSample    = 3
+	formatExpandedCounterSample = 4
+)
+
+// Flow record formats, enterprise 0
+const (
+	formatRawPacketHeader = 1
+	formatEthernetFrame   = 2
+	formatIPv4            = 3
+	formatIPv6            = 4
+	formatExtendedSwitch  = 1001
+	formatExtendedRouter  = 1002
+)
+
+// Counter record formats, enterprise 0
+const (
+	formatGenericInterface = 1
+)
+
+// Header protocols of the raw packet header flow record
+const (
+	headerProtocolEthernet = 1
+	headerProtocolIPv4     = 11
+	headerProtocolIPv6     = 12
+)
+
+const (
+	addressTypeIPv4 = 1
+	addressTypeIPv6 = 2
+)
+
+// Information elements used in the decoded records
+const (
+	ieProtocolIdentifier               = 4
+	ieIPClassOfService                 = 5
+	ieTCPControlBits                   = 6
+	ieSourceTransportPort              = 7
+	ieSourceIPv4Address                = 8
+	ieSourceIPv4PrefixLength           = 9
+	ieIngressInterface                 = 10
+	ieDestinationTransportPort         = 11
+	ieDestinationIPv4Address           = 12
+	ieDestinationIPv4PrefixLength      = 13
+	ieEgressInterface                  = 14
+	ieIPNextHopIPv4Address             = 15
+	ieSourceIPv6Address                = 27
+	ieDestinationIPv6Address           = 28
+	ieSourceIPv6PrefixLength           = 29
+	ieDestinationIPv6PrefixLength      = 30
+	ieSamplingInterval                 = 34
+	ieSourceMacAddress                 = 56
+	ieVlanID                           = 58
+	iePostVlanID                       = 59
+	ieIPNextHopIPv6Address             = 62
+	ieDestinationMacAddress            = 80
+	ieOctetTotalCount                  = 85
+	ieExporterIPv4Address              = 130
+	ieExporterIPv6Address              = 131
+	ieDroppedPacketTotalCount          = 135
+	iePostOctetTotalCount              = 171
+	iePostMCastPacketTotalCount        = 174
+	ieIPTTL                            = 192
+	ieIPTotalLength                    = 224
+	ieDot1qPriority                    = 244
+	ieEthernetType                     = 256
+	ieSamplingPopulation               = 310
+	ieDataLinkFrameSize                = 312
+	ieIPHeaderPacketSection            = 313
+	ieDataLinkFrameSection             = 315
+	ieIngressUnicastPacketTotalCount   = 354
+	ieIngressMulticastPacketTotalCount = 355
+	ieIngressBroadcastPacketTotalCount = 356
+	ieEgressUnicastPacketTotalCount    = 357
+	ieEgressBroadcastPacketTotalCount  = 358
+	ieIngressInterfaceType             = 368
+)
+
+// A Decoder decodes sFlow datagrams. It assigns a template ID to every record
+// layout it encounters and registers the template with its Session. A Decoder
+// is goroutine safe.
+type Decoder struct {
+	session *ipfix.Session
+
+	mut    sync.Mutex
+	ids    map[string]uint16    // template signature -> template ID
+	loaded map[templateKey]bool // templates registered with the session
+	nextID uint16
+}
+
+type templateKey struct {
+	scope ipfix.TemplateScope
+	id    uint16
+}
+
+// NewDecoder returns a new Decoder with an empty Session.
+func NewDecoder() *Decoder {
+	return &Decoder{
+		session: ipfix.NewSession(),
+		ids:     make(map[string]uint16),
+		loaded:  make(map[templateKey]bool),
+		nextID:  256,
+	}
+}
+
+// Session returns the session holding the templates of the decoded records.
+// Use it to create an ipfix.Interpreter, or to marshal decoded messages.
+func (d *Decoder) Session() *ipfix.Session {
+	return d.session
+}
+
+// Decode decodes an sFlow datagram. The returned message is an IPFIX message
+// without template records. Its header carries the sequence number, sub-agent
+// ID (as DomainID) and uptime of the datagram. The records are scoped by the
+// agent address and sub-agent ID.
+func (d *Decoder) Decode(bs []byte) (ipfix.Message, error) {
+	var msg ipfix.Message
+	r := reader{bs: bs}
+
+	if r.uint32() != sflowVersion {
+		if r.err != nil {
+			return msg, r.err
+		}
+		return msg, ipfix.ErrVersion
+	}
+
+	var agent []byte
+	var agentIE uint16
+	switch r.uint32() {
+	case addressTypeIPv4:
+		agent, agentIE = r.bytes(4), ieExporterIPv4Address
+	case addressTypeIPv6:
+		agent, agentIE = r.bytes(16), ieExporterIPv6Address
+	default:
+		if r.err != nil {
+			return msg, r.err
+		}
+		return msg, ipfix.ErrProtocol
+	}
+	msg.Header.Version = 10
+	msg.Header.DomainID = r.uint32()
+	msg.Header.SequenceNumber = r.uint32()
+	msg.Header.SysUptime = r.uint32()
+	samples := r.uint32()
+	if r.err != nil {
+		return msg, r.err
+	}
+
+	scope := ipfix.TemplateScope{Exporter: net.IP(agent).String(), DomainID: msg.Header.DomainID}
+	var drecs []ipfix.DataRecord
+	for i := uint32(0); i < samples; i++ {
+		format := r.uint32()
+		sr := reader{bs: r.bytes(int(r.uint32()))}
+		if r.err != nil {
+			return msg, r.err
+		}
+		if format>>12 != 0 {
+			// Not an sFlow.org sample
+			continue
+		}
+
+		var rec record
+		rec.add(agentIE, agent)
+		switch format {
+		case formatFlowSample, formatExpandedFlowSample:
+			decodeFlowSample(&sr, &rec, format == formatExpandedFlowSample)
+		case formatCounterSample, formatExpandedCounterSample:
+			decodeCounterSample(&sr, &rec, format == formatExpandedCounterSample)
+			if len(rec.specs) == 1 {
+				// No supported counter records
+				continue
+			}
+		default:
+			continue
+		}
+		if sr.err != nil {
+			return msg, sr.err
+		}
+
+		tid, err := d.templateID(scope, rec.specs)
+		if err != nil {
+			return msg, err
+		}
+		drecs = append(drecs, ipfix.DataRecord{TemplateID: tid, Scope: scope, Fields: rec.fields})
+	}
+	msg.DataRecords = drecs
+	return msg, nil
+}
+
+// templateID returns the ID of the template with the given field specifiers,
+// registering it with the session if needed.
+func (d *Decoder) templateID(scope ipfix.TemplateScope, specs []ipfix.TemplateFieldSpecifier) (uint16, error) {
+	sig := make([]byte, 0, len(specs)*4)
+	for _, s := range specs {
+		sig = append(sig, byte(s.FieldID>>8), byte(s.FieldID), byte(s.Length>>8), byte(s.Length))
+	}
+
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	id, ok := d.ids[string(sig)]
+	if !ok {
+		if d.nextID == 0 {
+			return 0, ErrTemplateSpace
+		}
+		id = d.nextID
+		d.nextID++
+		d.ids[string(sig)] = id
+	}
+	if key := (templateKey{scope, id}); !d.loaded[key] {
+		d.session.LoadTemplateRecords([]ipfix.TemplateRecord{{TemplateID: id, Scope: scope, FieldSpecifiers: specs}})
+		d.loaded[key] = true
+	}
+	return id, nil
+}
+
+// decodeFlowSample decodes a flow sample or expanded flow sample.
+func decodeFlowSample(r *reader, rec *record, expanded bool) {
+	var input, output uint32
+	var inputOK, outputOK bool
+
+	r.uint32() // sequence number
+	if expanded {
+		r.uint32() // source ID type
+		r.uint32() // source ID index
+	} else {
+		r.uint32() // source ID
+	}
+	rec.addUint32(ieSamplingInterval, r.uint32())
+	rec.addUint32(ieSamplingPopulation, r.uint32())
+	rec.addUint32(ieDroppedPacketTotalCount, r.uint32())
+	if expanded {
+		inputOK = r.uint32() == 0 // format 0 is an ifIndex
+		input = r.uint32()
+		outputOK = r.uint32() == 0
+		output = r.uint32()
+	} else {
+		// The top two bits hold the format
+		input, output = r.uint32(), r.uint32()
+		inputOK, outputOK = input>>30 == 0, output>>30 == 0
+	}
+	if inputOK {
+		rec.addUint32(ieIngressInterface, input)
+	}
+	if outputOK {
+		rec.addUint32(ieEgressInterface, output)
+	}
+
+	records := r.uint32()
+	for i := uint32(0); i < records && r.err == nil; i++ {
+		format := r.uint32()
+		fr := reader{bs: r.bytes(int(r.uint32()))}
+		if r.err != nil {
+			return
+		}
+		switch format {
+		case formatRawPacketHeader:
+			decodeRawPacketHeader(&fr, rec)
+		case formatEthernetFrame:
+			rec.addUint16(ieDataLinkFrameSize, uint16(fr.uint32()))
+			rec.add(ieSourceMacAddress, fr.mac())
+			rec.add(ieDestinationMacAddress, fr.mac())
+			rec.addUint16(ieEthernetType, uint16(fr.uint32()))
+		case formatIPv4, formatIPv6:
+			rec.addUint16(ieIPTotalLength, uint16(fr.uint32()))
+			rec.addUint8(ieProtocolIdentifier, uint8(fr.uint32()))
+			if format == formatIPv4 {
+				rec.add(ieSourceIPv4Address, fr.bytes(4))
+				rec.add(ieDestinationIPv4Address, fr.bytes(4))
+			} else {
+				rec.add(ieSourceIPv6Address, fr.bytes(16))
+				rec.add(ieDestinationIPv6Address, fr.bytes(16))
+			}
+			rec.addUint16(ieSourceTransportPort, uint16(fr.uint32()))
+			rec.addUint16(ieDestinationTransportPort, uint16(fr.uint32()))
+			rec.addUint16(ieTCPControlBits, uint16(fr.uint32()))
+			rec.addUint8(ieIPClassOfService, uint8(fr.uint32()))
+		case formatExtendedSwitch:
+			rec.addUint16(ieVlanID, uint16(fr.uint32()))
+			rec.addUint8(ieDot1qPriority, uint8(fr.uint32()))
+			rec.addUint16(iePostVlanID, uint16(fr.uint32()))
+		case formatExtendedRouter:
+			switch fr.uint32() {
+			case addressTypeIPv4:
+				rec.add(ieIPNextHopIPv4Address, fr.bytes(4))
+				rec.addUint8(ieSourceIPv4PrefixLength, uint8(fr.uint32()))
+				rec.addUint8(ieDestinationIPv4PrefixLength, uint8(fr.uint32()))
+			case addressTypeIPv6:
+				rec.add(ieIPNextHopIPv6Address, fr.bytes(16))
+				rec.addUint8(ieSourceIPv6PrefixLength, uint8(fr.uint32()))
+				rec.addUint8(ieDestinationIPv6PrefixLength, uint8(fr.uint32()))
+			}
+		}
+		if fr.err != nil {
+			r.err = fr.err
+		}
+	}
+}
+
+// decodeRawPacketHeader decodes the raw packet header flow record. The header
+// itself is kept, and the fields of its Ethernet, IP and transport headers
+// are added to the record.
+func decodeRawPacketHeader(r *reader, rec *record) {
+	protocol := r.uint32()
+	rec.addUint16(ieDataLinkFrameSize, uint16(r.uint32()))
+	r.uint32() // bytes stripped
+	hdr := r.bytes(int(r.uint32()))
+	if r.err != nil {
+		return
+	}
+
+	switch protocol {
+	case headerProtocolEthernet:
+		rec.addVariable(ieDataLinkFrameSection, hdr)
+		decodeEthernetHeader(hdr, rec)
+	case headerProtocolIPv4, headerProtocolIPv6:
+		rec.addVariable(ieIPHeaderPacketSection, hdr)
+		decodeIPHeader(hdr, rec)
+	}
+}
+
+// Ethernet types
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+)
+
+func decodeEthernetHeader(bs []byte, rec *record) {
+	if len(bs) < 14 {
+		return
+	}
+	rec.add(ieDestinationMacAddress, bs[0:6])
+	rec.add(ieSourceMacAddress, bs[6:12])
+	etherType := binary.BigEndian.Uint16(bs[12:14])
+	bs = bs[14:]
+	if etherType == etherTypeVLAN {
+		if len(bs) < 4 {
+			return
+		}
+		rec.addUint16(ieVlanID, binary.BigEndian.Uint16(bs[0:2])&0x0fff)
+		rec.addUint8(ieDot1qPriority, bs[0]>>5)
+		etherType = binary.BigEndian.Uint16(bs[2:4])
+		bs = bs[4:]
+	}
+	rec.addUint16(ieEthernetType, etherType)
+	if etherType == etherTypeIPv4 || etherType == etherTypeIPv6 {
+		decodeIPHeader(bs, rec)
+	}
+}
+
+// IP protocols with decoded headers
+const (
+	protocolTCP = 6
+	protocolUDP = 17
+)
+
+func decodeIPHeader(bs []byte, rec *record) {
+	if len(bs) < 1 {
+		return
+	}
+	var protocol uint8
+	switch bs[0] >> 4 {
+	case 4:
+		if len(bs) < 20 {
+			return
+		}
+		ihl := int(bs[0]&0x0f) * 4
+		protocol = bs[9]
+		rec.addUint8(ieIPClassOfService, bs[1])
+		rec.addUint16(ieIPTotalLength, binary.BigEndian.Uint16(bs[2:4]))
+		rec.addUint8(ieIPTTL, bs[8])
+		rec.addUint8(ieProtocolIdentifier, protocol)
+		rec.add(ieSourceIPv4Address, bs[12:16])
+		rec.add(ieDestinationIPv4Address, bs[16:20])
+		if ihl < 20 || len(bs) < ihl {
+			return
+		}
+		bs = bs[ihl:]
+	case 6:
+		if len(bs) < 40 {
+			return
+		}
+		protocol = bs[6]
+		rec.addUint8(ieIPClassOfService, uint8(binary.BigEndian.Uint16(bs[0:2])>>4))
+		rec.addUint16(ieIPTotalLength, binary.BigEndian.Uint16(bs[4:6])+40)
+		rec.addUint8(ieIPTTL, bs[7])
+		rec.addUint8(ieProtocolIdentifier, protocol)
+		rec.add(ieSourceIPv6Address, bs[8:24])
+		rec.add(ieDestinationIPv6Address, bs[24:40])
+		// Extension headers are not followed
+		bs = bs[40:]
+	default:
+		return
+	}
+
+	switch protocol {
+	case protocolTCP:
+		if len(bs) < 14 {
+			return
+		}
+		rec.add(ieSourceTransportPort, bs[0:2])
+		rec.add(ieDestinationTransportPort, bs[2:4])
+		rec.addUint16(ieTCPControlBits, binary.BigEndian.Uint16(bs[12:14])&0x0fff)
+	case protocolUDP:
+		if len(bs) < 4 {
+			return
+		}
+		rec.add(ieSourceTransportPort, bs[0:2])
+		rec.add(ieDestinationTransportPort, bs[2:4])
+	}
+}
+
+// decodeCounterSample decodes a counter sample or expanded counter sample.
+func decodeCounterSample(r *reader, rec *record, expanded bool) {
+	r.uint32() // sequence number
+	if expanded {
+		r.uint32() // source ID type
+		r.uint32() // source ID index
+	} else {
+		r.uint32() // source ID
+	}
+
+	records := r.uint32()
+	for i := uint32(0); i < records && r.err == nil; i++ {
+		format := r.uint32()
+		cr := reader{bs: r.bytes(int(r.uint32()))}
+		if r.err != nil {
+			return
+		}
+		switch format {
+		case formatGenericInterface:
+			rec.addUint32(ieIngressInterface, cr.uint32())
+			rec.addUint32(ieIngressInterfaceType, cr.uint32())
+			cr.uint64() // ifSpeed
+			cr.uint32() // ifDirection
+			cr.uint32() // ifStatus
+			rec.addUint64(ieOctetTotalCount, cr.uint64())
+			rec.addUint32(ieIngressUnicastPacketTotalCount, cr.uint32())
+			rec.addUint32(ieIngressMulticastPacketTotalCount, cr.uint32())
+			rec.addUint32(ieIngressBroadcastPacketTotalCount, cr.uint32())
+			rec.addUint32(ieDroppedPacketTotalCount, cr.uint32())
+			cr.uint32() // ifInErrors
+			cr.uint32() // ifInUnknownProtos
+			rec.addUint64(iePostOctetTotalCount, cr.uint64())
+			rec.addUint32(ieEgressUnicastPacketTotalCount, cr.uint32())
+			rec.addUint32(iePostMCastPacketTotalCount, cr.uint32())
+			rec.addUint32(ieEgressBroadcastPacketTotalCount, cr.uint32())
+		}
+		if cr.err != nil {
+			r.err = cr.err
+		}
+	}
+}
+
+// A record collects the fields of a data record and its template. Each
+// information element is only added once, the first value wins.
+type record struct {
+	specs  []ipfix.TemplateFieldSpecifier
+	fields [][]byte
+}
+
+func (r *record) has(id uint16) bool {
+	for _, s := range r.specs {
+		if s.FieldID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *record) add(id uint16, val []byte) {
+	if val == nil || r.has(id) {
+		return
+	}
+	cp := make([]byte, len(val))
+	copy(cp, val)
+	r.specs = append(r.specs, ipfix.TemplateFieldSpecifier{FieldID: id, Length: uint16(len(cp))})
+	r.fields = append(r.fields, cp)
+}
+
+func (r *record) addVariable(id uint16, val []byte) {
+	if val == nil || r.has(id) {
+		return
+	}
+	cp := make([]byte, len(val))
+	copy(cp, val)
+	r.specs = append(r.specs, ipfix.TemplateFieldSpecifier{FieldID: id, Length: 0xffff})
+	r.fields = append(r.fields, cp)
+}
+
+func (r *record) addUint8(id uint16, v uint8) {
+	r.add(id, []byte{v})
+}
+
+func (r *record) addUint16(id uint16, v uint16) {
+	var bs [2]byte
+	binary.BigEndian.PutUint16(bs[:], v)
+	r.add(id, bs[:])
+}
+
+func (r *record) addUint32(id uint16, v uint32) {
+	var bs [4]byte
+	binary.BigEndian.PutUint32(bs[:], v)
+	r.add(id, bs[:])
+}
+
+func (r *record) addUint64(id uint16, v uint64) {
+	var bs [8]byte
+	binary.BigEndian.PutUint64(bs[:], v)
+	r.add(id, bs[:])
+}
+
+// A reader reads XDR encoded values. After the first short read all further
+// reads return zero values, and err is set to ipfix.ErrRead.
+type reader struct {
+	bs  []byte
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	// Opaque data is padded to a multiple of four bytes
+	padded := (n + 3) &^ 3
+	if r.err != nil || n < 0 || padded > len(r.bs) {
+		r.err = ipfix.ErrRead
+		return nil
+	}
+	bs := r.bs[:n]
+	r.bs = r.bs[padded:]
+	return bs
+}
+
+func (r *reader) uint32() uint32 {
+	bs := r.bytes(4)
+	if bs == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(bs)
+}
+
+func (r *reader) uint64() uint64 {
+	bs := r.bytes(8)
+	if bs == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(bs)
+}
+
+// mac reads a MAC address, which is padded to eight bytes.
+func (r *reader) mac() []byte {
+	bs := r.bytes(8)
+	if bs == nil {
+		return nil
+	}
+	return bs[:6]
+}
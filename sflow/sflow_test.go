@@ -0,0 +1,188 @@
+package sflow
+
+import (
+	"encoding/hex"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/gravwell/ipfix"
+)
+
+// A datagram from agent 192.0.2.1 with a flow sample (raw Ethernet/IPv4/TCP
+// header and extended switch data), an unknown enterprise sample and a
+// counter sample with generic interface counters.
+var datagram, _ = hex.DecodeString("0000000500000001c0000201000000030000002a000186a00000000300000001000000880000000700000005000003e8000013880000000200000005000000060000000200000001000000480000000100000062000000040000003600112233445566778899aabb08004500005400004000400600000a0000010a0000021f90005000000000000000005012ffff000000000000000003e9000000100000000a000000030000001400000000012340010000000400000001000000020000006c00000008000000050000000100000001000000580000000500000006000000003b9aca00000000010000000300000000075bcd15000003e80000000a00000005000000010000000000000000000000003ade68b1000007d00000001400000006000000000000000000000000")
+
+func interpret(t *testing.T, d *Decoder, rec ipfix.DataRecord) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, f := range ipfix.NewInterpreter(d.Session()).Interpret(rec) {
+		if f.Name == "" {
+			t.Errorf("Unknown field %d/%d", f.EnterpriseID, f.FieldID)
+		}
+		values[f.Name] = f.Value
+	}
+	return values
+}
+
+func TestDecode(t *testing.T) {
+	d := NewDecoder()
+	msg, err := d.Decode(datagram)
+	if err != nil {
+		t.Fatal("Decode failed", err)
+	}
+
+	if msg.Header.Version != 10 || msg.Header.DomainID != 3 || msg.Header.SequenceNumber != 42 || msg.Header.SysUptime != 100000 {
+		t.Errorf("Incorrect header %+v", msg.Header)
+	}
+	if len(msg.DataRecords) != 2 {
+		t.Fatal("Incorrect number of data records", len(msg.DataRecords))
+	}
+	scope := ipfix.TemplateScope{Exporter: "192.0.2.1", DomainID: 3}
+	for _, dr := range msg.DataRecords {
+		if dr.Scope != scope {
+			t.Errorf("Incorrect scope %+v", dr.Scope)
+		}
+	}
+
+	flow := interpret(t, d, msg.DataRecords[0])
+	if ip, ok := flow["sourceIPv4Address"].(*net.IP); !ok || !ip.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Error("Incorrect sourceIPv4Address", flow["sourceIPv4Address"])
+	}
+	if ip, ok := flow["exporterIPv4Address"].(*net.IP); !ok || !ip.Equal(net.IPv4(192, 0, 2, 1)) {
+		t.Error("Incorrect exporterIPv4Address", flow["exporterIPv4Address"])
+	}
+	wantFlow := map[string]interface{}{
+		"samplingInterval":         uint32(1000),
+		"samplingPopulation":       uint32(5000),
+		"droppedPacketTotalCount":  uint64(2),
+		"ingressInterface":         uint32(5),
+		"egressInterface":          uint32(6),
+		"dataLinkFrameSize":        uint16(98),
+		"ethernetType":             uint16(0x0800),
+		"protocolIdentifier":       uint8(6),
+		"ipTTL":                    uint8(64),
+		"sourceTransportPort":      uint16(8080),
+		"destinationTransportPort": uint16(80),
+		"tcpControlBits":           uint16(0x12),
+		"vlanId":                   uint16(10),
+		"dot1qPriority":            uint8(3),
+		"postVlanId":               uint16(20),
+	}
+	for name, want := range wantFlow {
+		if flow[name] != want {
+			t.Errorf("Incorrect %s %v (%T), want %v (%T)", name, flow[name], flow[name], want, want)
+		}
+	}
+	if hdr, ok := flow["dataLinkFrameSection"].([]byte); !ok || len(hdr) != 54 {
+		t.Error("Incorrect dataLinkFrameSection", flow["dataLinkFrameSection"])
+	}
+
+	counters := interpret(t, d, msg.DataRecords[1])
+	wantCounters := map[string]interface{}{
+		"ingressInterface":                 uint32(5),
+		"ingressInterfaceType":             uint32(6),
+		"octetTotalCount":                  uint64(123456789),
+		"ingressUnicastPacketTotalCount":   uint64(1000),
+		"ingressMulticastPacketTotalCount": uint64(10),
+		"ingressBroadcastPacketTotalCount": uint64(5),
+		"droppedPacketTotalCount":          uint64(1),
+		"postOctetTotalCount":              uint64(987654321),
+		"egressUnicastPacketTotalCount":    uint64(2000),
+		"postMCastPacketTotalCount":        uint64(20),
+		"egressBroadcastPacketTotalCount":  uint64(6),
+	}
+	for name, want := range wantCounters {
+		if counters[name] != want {
+			t.Errorf("Incorrect %s %v (%T), want %v (%T)", name, counters[name], counters[name], want, want)
+		}
+	}
+
+	// The same layouts get the same templates
+	again, err := d.Decode(datagram)
+	if err != nil {
+		t.Fatal("Decode failed", err)
+	}
+	for i := range again.DataRecords {
+		if again.DataRecords[i].TemplateID != msg.DataRecords[i].TemplateID {
+			t.Error("Template ID changed", again.DataRecords[i].TemplateID, msg.DataRecords[i].TemplateID)
+		}
+	}
+	if msg.DataRecords[0].TemplateID == msg.DataRecords[1].TemplateID {
+		t.Error("Flow and counter samples share a template")
+	}
+}
+
+func TestDecodeMarshal(t *testing.T) {
+	d := NewDecoder()
+	msg, err := d.Decode(datagram)
+	if err != nil {
+		t.Fatal("Decode failed", err)
+	}
+
+	// The decoded message can be sent on as IPFIX
+	if msg.TemplateRecords, err = d.Session().LookupTemplateRecords(msg); err != nil {
+		t.Fatal("LookupTemplateRecords failed", err)
+	}
+	bs, err := d.Session().Marshal(msg)
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	parsed, err := ipfix.NewSession().ParseBuffer(bs)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(parsed.DataRecords) != len(msg.DataRecords) {
+		t.Fatal("Incorrect number of data records", len(parsed.DataRecords))
+	}
+	for i := range msg.DataRecords {
+		if !reflect.DeepEqual(parsed.DataRecords[i].Fields, msg.DataRecords[i].Fields) {
+			t.Errorf("Data record %d differs", i)
+		}
+	}
+}
+
+func TestDecodeFilter(t *testing.T) {
+	d := NewDecoder()
+	msg, err := d.Decode(datagram)
+	if err != nil {
+		t.Fatal("Decode failed", err)
+	}
+	trecs, err := d.Session().LookupTemplateRecords(msg)
+	if err != nil {
+		t.Fatal("LookupTemplateRecords failed", err)
+	}
+
+	var f ipfix.Filter
+	f.Set(0, ieSourceIPv4Address)
+	var hits int
+	for _, tr := range trecs {
+		for _, fs := range tr.FieldSpecifiers {
+			if f.IsSet(fs.EnterpriseID, fs.FieldID) {
+				hits++
+			}
+		}
+	}
+	if hits != 1 {
+		t.Error("Incorrect number of filter hits", hits)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	d := NewDecoder()
+	if _, err := d.Decode(datagram[:len(datagram)-4]); err != ipfix.ErrRead {
+		t.Error("Expected ErrRead for a truncated datagram, got", err)
+	}
+
+	bs := append([]byte(nil), datagram...)
+	bs[3] = 4
+	if _, err := d.Decode(bs); err != ipfix.ErrVersion {
+		t.Error("Expected ErrVersion, got", err)
+	}
+
+	bs = append([]byte(nil), datagram...)
+	bs[7] = 3
+	if _, err := d.Decode(bs); err != ipfix.ErrProtocol {
+		t.Error("Expected ErrProtocol, got", err)
+	}
+}
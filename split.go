@@ -0,0 +1,256 @@
+package ipfix
+
+// templateFunc returns the template with the given ID, and its scope field
+// count if it is an options template.
+type templateFunc func(TemplateScope, uint16) (TemplateRecord, uint16, bool)
+
+// MarshalSplit works like Marshal, but spreads the templates and records of
+// m over as many messages as needed to keep each at most maxSize bytes long.
+// Records are never split, but a set may be spread over several messages.
+//
+// The first message has the sequence number of m. The following messages
+// continue it, counting the data records of the messages before them for
+// IPFIX, Netflow v5 and v7, or the messages before them for NFv9.
+//
+// If withTemplates is set, every message carries the templates of its
+// records, taken from the Session. This lets a collector decode each message
+// on its own, as when exporting over UDP. Otherwise the templates of m are
+// only sent in the first messages.
+func (s *Session) MarshalSplit(m Message, maxSize int, withTemplates bool) ([][]byte, error) {
+	return m.marshalSplit(maxSize, withTemplates, s.lookupUnaliasedTemplateFieldSpecifiers, s.templateRecord)
+}
+
+// MarshalSplit works like Session.MarshalSplit for a stand alone message.
+// Every template referred to by its data records must be in the message.
+func (m Message) MarshalSplit(maxSize int, withTemplates bool) ([][]byte, error) {
+	return m.marshalSplit(maxSize, withTemplates, m.lookupScopedTemplateFieldSpecifiers, m.templateRecord)
+}
+
+// templateRecord returns a template known to the session, as a templateFunc.
+func (s *Session) templateRecord(scope TemplateScope, tid uint16) (TemplateRecord, uint16, bool) {
+	fs := s.lookupUnaliasedTemplateFieldSpecifiers(scope, tid)
+	if len(fs) == 0 {
+		return TemplateRecord{}, 0, false
+	}
+	return TemplateRecord{TemplateID: tid, Scope: scope, FieldSpecifiers: fs}, s.lookupScopeFieldCount(scope, tid), true
+}
+
+// templateRecord returns a template of the message, as a templateFunc.
+func (m Message) templateRecord(_ TemplateScope, tid uint16) (TemplateRecord, uint16, bool) {
+	for _, tr := range m.TemplateRecords {
+		if tr.TemplateID == tid {
+			return tr, 0, true
+		}
+	}
+	for _, otr := range m.OptionsTemplateRecords {
+		if otr.TemplateID == tid {
+			return TemplateRecord{TemplateID: tid, Scope: otr.Scope, FieldSpecifiers: otr.FieldSpecifiers}, otr.ScopeFieldCount, true
+		}
+	}
+	return TemplateRecord{}, 0, false
+}
+
+func (m Message) marshalSplit(maxSize int, withTemplates bool, lu lookupFunc, tf templateFunc) ([][]byte, error) {
+	if maxSize > maxMessageLength {
+		maxSize = maxMessageLength
+	}
+	if isFixedFormat(m.Header.Version) {
+		return m.marshalFixedSplit(maxSize)
+	}
+
+	drecs := m.dataRecords()
+	used := make(map[uint16]bool)
+	if withTemplates {
+		for _, dr := range drecs {
+			used[dr.TemplateID] = true
+		}
+	}
+
+	sp := splitter{maxSize: maxSize, header: m.Header}
+	sp.reset()
+	// Templates without records go first, the others with their records
+	for _, tr := range m.TemplateRecords {
+		if !used[tr.TemplateID] {
+			if err := sp.addTemplate(tr, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, otr := range m.OptionsTemplateRecords {
+		if !used[otr.TemplateID] {
+			tr := TemplateRecord{TemplateID: otr.TemplateID, Scope: otr.Scope, FieldSpecifiers: otr.FieldSpecifiers}
+			if err := sp.addTemplate(tr, otr.ScopeFieldCount); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !withTemplates {
+		tf = nil
+	}
+	for _, dr := range drecs {
+		tpl := lu(dr.Scope, dr.TemplateID)
+		if len(tpl) == 0 {
+			return nil, ErrUnknownTemplate
+		}
+		n, err := marshalledRecordLength(tpl, dr.Fields)
+		if err != nil {
+			return nil, err
+		}
+		if err := sp.addRecord(dr, n, tf); err != nil {
+			return nil, err
+		}
+	}
+	if !sp.fresh() || len(sp.msgs) == 0 {
+		sp.flush()
+	}
+
+	bss := make([][]byte, 0, len(sp.msgs))
+	for _, msg := range sp.msgs {
+		bs, err := msg.marshal(lu)
+		if err != nil {
+			return nil, err
+		}
+		bss = append(bss, bs)
+	}
+	return bss, nil
+}
+
+// splitter packs templates and data records into messages of limited size.
+type splitter struct {
+	maxSize int
+	header  MessageHeader // of the next message
+	msgs    []Message
+
+	cur       Message // templates of the current message
+	templates map[uint16]bool
+	headLen   int // length of cur up to the data sets
+	records   []DataRecord
+	dataLen   int
+}
+
+// reset starts a new message.
+func (sp *splitter) reset() {
+	sp.cur = Message{Header: sp.header}
+	sp.templates = make(map[uint16]bool)
+	sp.headLen, _, _, _ = sp.cur.calculateMarshalledLength(nil)
+	sp.records = nil
+	sp.dataLen = 0
+}
+
+// fresh returns true if nothing has been added to the current message.
+func (sp *splitter) fresh() bool {
+	return len(sp.templates) == 0 && len(sp.records) == 0
+}
+
+// flush ends the current message and starts the next one.
+func (sp *splitter) flush() {
+	sp.cur.DataRecords = sp.records
+	sp.msgs = append(sp.msgs, sp.cur)
+	if sp.header.Version == nfv9Version {
+		sp.header.SequenceNumber++
+	} else {
+		sp.header.SequenceNumber += uint32(len(sp.records))
+	}
+	sp.reset()
+}
+
+// withTemplate returns the templates of the current message with tr added,
+// and their length.
+func (sp *splitter) withTemplate(tr TemplateRecord, scopeCount uint16) (Message, int) {
+	m := sp.cur
+	if scopeCount > 0 {
+		otrs := m.OptionsTemplateRecords[:len(m.OptionsTemplateRecords):len(m.OptionsTemplateRecords)]
+		m.OptionsTemplateRecords = append(otrs, OptionsTemplateRecord{
+			TemplateID:      tr.TemplateID,
+			Scope:           tr.Scope,
+			ScopeFieldCount: scopeCount,
+			FieldSpecifiers: tr.FieldSpecifiers,
+		})
+	} else {
+		m.TemplateRecords = append(m.TemplateRecords[:len(m.TemplateRecords):len(m.TemplateRecords)], tr)
+	}
+	n, _, _, _ := m.calculateMarshalledLength(nil)
+	return m, n
+}
+
+// addTemplate adds a template to the current message, or to the next one if
+// it does not fit.
+func (sp *splitter) addTemplate(tr TemplateRecord, scopeCount uint16) error {
+	m, n := sp.withTemplate(tr, scopeCount)
+	if n+sp.dataLen > sp.maxSize {
+		if sp.fresh() {
+			return ErrFieldOverflow
+		}
+		sp.flush()
+		if m, n = sp.withTemplate(tr, scopeCount); n > sp.maxSize {
+			return ErrFieldOverflow
+		}
+	}
+	sp.cur, sp.headLen = m, n
+	sp.templates[tr.TemplateID] = true
+	return nil
+}
+
+// addRecord adds a data record of length n to the current message, or to
+// the next one if it does not fit. If tf is set, the template of the record
+// is added to the message as well unless it is there already.
+func (sp *splitter) addRecord(dr DataRecord, n int, tf templateFunc) error {
+	for {
+		m, headLen := sp.cur, sp.headLen
+		if tf != nil && !sp.templates[dr.TemplateID] {
+			tr, scopeCount, ok := tf(dr.Scope, dr.TemplateID)
+			if !ok {
+				return ErrUnknownTemplate
+			}
+			m, headLen = sp.withTemplate(tr, scopeCount)
+		}
+		length := n
+		if last := len(sp.records) - 1; last < 0 || sp.records[last].TemplateID != dr.TemplateID || sp.records[last].Scope != dr.Scope {
+			length += setHeaderLength
+		}
+
+		if headLen+sp.dataLen+length <= sp.maxSize {
+			sp.cur, sp.headLen = m, headLen
+			sp.templates[dr.TemplateID] = true
+			sp.records = append(sp.records, dr)
+			sp.dataLen += length
+			return nil
+		}
+		if sp.fresh() {
+			return ErrFieldOverflow
+		}
+		sp.flush()
+	}
+}
+
+// marshalFixedSplit splits a fixed format message, which carries at most
+// maxRecords records.
+func (m Message) marshalFixedSplit(maxSize int) ([][]byte, error) {
+	ff := fixedFormats[m.Header.Version]
+	per := (maxSize - ff.headerLength) / int(calcMinRecLen(fixedTemplates[ff.templateID]))
+	if per > ff.maxRecords {
+		per = ff.maxRecords
+	} else if per < 1 {
+		return nil, ErrFieldOverflow
+	}
+
+	var bss [][]byte
+	drecs := m.DataRecords
+	for {
+		n := per
+		if n > len(drecs) {
+			n = len(drecs)
+		}
+		msg := Message{Header: m.Header, DataRecords: drecs[:n], OptionsDataRecords: m.OptionsDataRecords}
+		bs, err := msg.marshalFixedFormat()
+		if err != nil {
+			return nil, err
+		}
+		bss = append(bss, bs)
+		m.Header.SequenceNumber += uint32(n)
+		if drecs = drecs[n:]; len(drecs) == 0 {
+			return bss, nil
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ipfix
+
+import (
+	"testing"
+)
+
+func splitMessage(version uint16, records int) Message {
+	m := Message{
+		Header:          MessageHeader{Version: version, SequenceNumber: 1000, DomainID: 1},
+		TemplateRecords: []TemplateRecord{exportTemplate},
+	}
+	for i := 0; i < records; i++ {
+		m.DataRecords = append(m.DataRecords, exportRecord(byte(i)))
+	}
+	return m
+}
+
+func TestMarshalSplitWithTemplates(t *testing.T) {
+	bss, err := splitMessage(10, 100).MarshalSplit(200, true)
+	if err != nil {
+		t.Fatal("MarshalSplit failed", err)
+	}
+	if len(bss) != 8 {
+		t.Fatal("Incorrect number of messages", len(bss))
+	}
+	var i byte
+	for n, bs := range bss {
+		if len(bs) > 200 {
+			t.Error("Message exceeds maximum size", len(bs))
+		}
+		// Every message can be parsed on its own
+		msg, err := NewSession().ParseBuffer(bs)
+		if err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if len(msg.TemplateRecords) != 1 {
+			t.Error("Incorrect number of template records", len(msg.TemplateRecords))
+		}
+		if msg.Header.SequenceNumber != 1000+13*uint32(n) {
+			t.Error("Incorrect sequence number", msg.Header.SequenceNumber)
+		}
+		for _, dr := range msg.DataRecords {
+			if dr.Fields[2][3] != i {
+				t.Error("Incorrect record", dr.Fields)
+			}
+			i++
+		}
+	}
+	if i != 100 {
+		t.Error("Incorrect number of data records", i)
+	}
+}
+
+func TestMarshalSplit(t *testing.T) {
+	m := splitMessage(10, 100)
+	s := NewSession()
+	s.LoadTemplateRecords(m.TemplateRecords)
+	m.TemplateRecords = nil
+	bss, err := s.MarshalSplit(m, 200, false)
+	if err != nil {
+		t.Fatal("MarshalSplit failed", err)
+	}
+	if len(bss) != 7 {
+		t.Fatal("Incorrect number of messages", len(bss))
+	}
+	p := NewSession()
+	tr := exportTemplate
+	tr.Scope = TemplateScope{DomainID: 1}
+	p.LoadTemplateRecords([]TemplateRecord{tr})
+	var records int
+	for _, bs := range bss {
+		msg, err := p.ParseBuffer(bs)
+		if err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if len(msg.TemplateRecords) != 0 {
+			t.Error("Incorrect number of template records", len(msg.TemplateRecords))
+		}
+		if msg.Header.SequenceNumber != 1000+uint32(records) {
+			t.Error("Incorrect sequence number", msg.Header.SequenceNumber)
+		}
+		records += len(msg.DataRecords)
+	}
+	if records != 100 {
+		t.Error("Incorrect number of data records", records)
+	}
+	if st := p.SequenceStats()[TemplateScope{DomainID: 1}]; st.Lost != 0 {
+		t.Errorf("Incorrect sequence stats %+v", st)
+	}
+}
+
+func TestMarshalSplitNFv9(t *testing.T) {
+	bss, err := splitMessage(9, 40).MarshalSplit(200, true)
+	if err != nil {
+		t.Fatal("MarshalSplit failed", err)
+	}
+	if len(bss) != 4 {
+		t.Fatal("Incorrect number of messages", len(bss))
+	}
+	for n, bs := range bss {
+		msg, err := NewSession().ParseBuffer(bs)
+		if err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if msg.Header.SequenceNumber != 1000+uint32(n) {
+			t.Error("Incorrect sequence number", msg.Header.SequenceNumber)
+		}
+	}
+}
+
+func TestMarshalSplitFixed(t *testing.T) {
+	tr, _ := FixedTemplateRecord(5)
+	m := Message{Header: MessageHeader{Version: 5}}
+	for i := 0; i < 70; i++ {
+		dr := DataRecord{TemplateID: tr.TemplateID}
+		for _, fs := range tr.FieldSpecifiers {
+			dr.Fields = append(dr.Fields, make([]byte, fs.Length))
+		}
+		m.DataRecords = append(m.DataRecords, dr)
+	}
+	bss, err := m.MarshalSplit(maxMessageLength, false)
+	if err != nil {
+		t.Fatal("MarshalSplit failed", err)
+	}
+	p := NewSession()
+	for n, want := range []int{30, 30, 10} {
+		msg, err := p.ParseBuffer(bss[n])
+		if err != nil {
+			t.Fatal("ParseBuffer failed", err)
+		}
+		if len(msg.DataRecords) != want || msg.Header.SequenceNumber != 30*uint32(n) {
+			t.Errorf("Message %d has %d records and sequence number %d", n, len(msg.DataRecords), msg.Header.SequenceNumber)
+		}
+	}
+}
+
+func TestMarshalTooLong(t *testing.T) {
+	m := splitMessage(10, 6000)
+	if _, err := m.Marshal(); err != ErrMessageTooLong {
+		t.Error("Expected ErrMessageTooLong, got", err)
+	}
+	if _, err := m.MarshalSplit(30, true); err != ErrFieldOverflow {
+		t.Error("Expected ErrFieldOverflow, got", err)
+	}
+	bss, err := m.MarshalSplit(maxMessageLength, false)
+	if err != nil {
+		t.Fatal("MarshalSplit failed", err)
+	}
+	if len(bss) != 2 {
+		t.Error("Incorrect number of messages", len(bss))
+	}
+}
+
+func TestMarshalRecordError(t *testing.T) {
+	m := Message{
+		Header:          MessageHeader{Version: 10, DomainID: 1},
+		TemplateRecords: []TemplateRecord{exportTemplate},
+		DataRecords:     []DataRecord{exportRecord(1)},
+	}
+	// The last field is longer than its template says
+	m.DataRecords[0].Fields[2] = make([]byte, 8)
+	if bs, err := m.Marshal(); err != ErrFieldOverflow || len(bs) > 0 {
+		t.Errorf("Expected ErrFieldOverflow, got %x, %v", bs, err)
+	}
+}
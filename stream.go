@@ -0,0 +1,259 @@
+package ipfix
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+// maxMessageLength is the largest message the StreamDecoder frames. IPFIX
+// messages are limited by their 16 bit length field, Netflow v9 packets by
+// the UDP datagram size.
+const maxMessageLength = 65535
+
+// A peeker returns the next n bytes of input without consuming them. If fewer
+// bytes are available it returns them along with an error.
+type peeker interface {
+	Peek(n int) ([]byte, error)
+}
+
+// bytesPeeker is a peeker over a buffer.
+type bytesPeeker []byte
+
+func (p bytesPeeker) Peek(n int) ([]byte, error) {
+	if n > len(p) {
+		return p, io.EOF
+	}
+	return p[:n], nil
+}
+
+// frameIPFIX returns the length of the IPFIX message at the start of p. It
+// returns ErrProtocol unless the sets fill the message exactly.
+func frameIPFIX(p peeker) (int, error) {
+	hdr, err := p.Peek(msgIpfixHeaderLength)
+	if err != nil {
+		return 0, err
+	}
+	length := int(binary.BigEndian.Uint16(hdr[2:]))
+	if length < msgIpfixHeaderLength {
+		return 0, ErrProtocol
+	}
+	bs, err := p.Peek(length)
+	if err != nil {
+		return 0, err
+	}
+	for off := msgIpfixHeaderLength; off < length; {
+		if length-off < setHeaderLength {
+			return 0, ErrProtocol
+		}
+		setLen := int(binary.BigEndian.Uint16(bs[off+2:]))
+		if setLen < setHeaderLength || off+setLen > length {
+			return 0, ErrProtocol
+		}
+		off += setLen
+	}
+	return length, nil
+}
+
+// frameNFv9 returns the length of the Netflow v9 packet at the start of p.
+// NFv9 has no packet length, so the flowsets are read until the record count
+// of the header is reached. Templates are taken from the packet itself or the
+// session. If a data flowset has an unknown template its records can not be
+// counted, and the flowsets are walked by their length until the next packet
+// begins or the input ends. Such a packet is thus framed only once the start
+// of the next one has been read. It returns ErrProtocol if a flowset header
+// is invalid.
+func (s *Session) frameNFv9(p peeker, exporter string) (int, error) {
+	hdr, err := p.Peek(msgNFv9HeaderLength)
+	if err != nil {
+		return 0, err
+	}
+	count := int(binary.BigEndian.Uint16(hdr[2:]))
+	scope := TemplateScope{Exporter: exporter, DomainID: binary.BigEndian.Uint32(hdr[16:])}
+	recLens := make(map[uint16]int)
+
+	off := msgNFv9HeaderLength
+	records := 0
+	counting := true
+	for !counting || records < count {
+		if !counting {
+			next, err := p.Peek(off + 2)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return 0, err
+			}
+			// Flowset IDs 2-255 are reserved, so this is the next packet
+			if v := binary.BigEndian.Uint16(next[off:]); v == nfv9Version || v == ipfixVersion {
+				break
+			}
+		}
+
+		bs, err := p.Peek(off + setHeaderLength)
+		if err != nil {
+			return 0, err
+		}
+		id := binary.BigEndian.Uint16(bs[off:])
+		setLen := int(binary.BigEndian.Uint16(bs[off+2:]))
+		if setLen < setHeaderLength || (id > 1 && id < 256) || off+setLen > maxMessageLength {
+			return 0, ErrProtocol
+		}
+		if bs, err = p.Peek(off + setLen); err != nil {
+			return 0, err
+		}
+		body := bs[off+setHeaderLength : off+setLen]
+
+		switch id {
+		case 0:
+			records += countNFv9Templates(body, recLens)
+		case 1:
+			records += countNFv9OptionsTemplates(body, recLens)
+		default:
+			recLen, ok := recLens[id]
+			if !ok {
+				recLen = int(s.getMinRecLen(scope, id))
+			}
+			if recLen == 0 {
+				counting = false
+			} else {
+				records += len(body) / recLen
+			}
+		}
+		off += setLen
+	}
+	return off, nil
+}
+
+// countNFv9Templates returns the number of template records in the body of
+// a template flowset, and stores their record lengths in recLens.
+func countNFv9Templates(body []byte, recLens map[uint16]int) int {
+	n := 0
+	for len(body) >= templateHeaderLength {
+		tid := binary.BigEndian.Uint16(body)
+		end := templateHeaderLength + 4*int(binary.BigEndian.Uint16(body[2:]))
+		if tid < 256 || end > len(body) {
+			// Padding
+			break
+		}
+		recLens[tid] = sumFieldLengths(body[templateHeaderLength:end])
+		body = body[end:]
+		n++
+	}
+	return n
+}
+
+// countNFv9OptionsTemplates works like countNFv9Templates for options
+// template flowsets.
+func countNFv9OptionsTemplates(body []byte, recLens map[uint16]int) int {
+	n := 0
+	for len(body) >= nfv9OptionsTemplateHeaderLength {
+		tid := binary.BigEndian.Uint16(body)
+		end := nfv9OptionsTemplateHeaderLength + int(binary.BigEndian.Uint16(body[2:])) + int(binary.BigEndian.Uint16(body[4:]))
+		if tid < 256 || end > len(body) {
+			// Padding
+			break
+		}
+		recLens[tid] = sumFieldLengths(body[nfv9OptionsTemplateHeaderLength:end])
+		body = body[end:]
+		n++
+	}
+	return n
+}
+
+// sumFieldLengths returns the total length of the NFv9 field specifiers
+// (type and length) in bs.
+func sumFieldLengths(bs []byte) int {
+	var l int
+	for ; len(bs) >= 4; bs = bs[4:] {
+		l += int(binary.BigEndian.Uint16(bs[2:]))
+	}
+	return l
+}
+
+// A StreamDecoder reads IPFIX messages and Netflow v9 packets from a stream,
+// such as an IPFIX over TCP connection or a file of captured packets. IPFIX
+// messages are framed by the length in their header, NFv9 packets by their
+// record count. If the data at the current position does not look like a
+// message, the decoder skips ahead byte by byte until it finds one.
+//
+// A StreamDecoder is not goroutine safe, but any number of decoders may share
+// a Session.
+type StreamDecoder struct {
+	s        *Session
+	r        *bufio.Reader
+	exporter string
+	skipped  int64
+}
+
+// NewStreamDecoder returns a StreamDecoder reading from r and parsing the
+// messages with s. Templates are scoped by the observation domain only.
+func NewStreamDecoder(s *Session, r io.Reader) *StreamDecoder {
+	return NewStreamDecoderFrom(s, r, nil)
+}
+
+// NewStreamDecoderFrom works like NewStreamDecoder, but scopes the templates
+// by the given exporter address as well, see ParseBufferFrom.
+func NewStreamDecoderFrom(s *Session, r io.Reader, addr net.Addr) *StreamDecoder {
+	var exporter string
+	if addr != nil {
+		exporter = addr.String()
+	}
+	return &StreamDecoder{
+		s:        s,
+		r:        bufio.NewReaderSize(r, 2*maxMessageLength),
+		exporter: exporter,
+	}
+}
+
+// Decode reads and parses the next message. It returns io.EOF at the end of
+// the stream, and io.ErrUnexpectedEOF if the stream ends within a message.
+// Other read errors are returned as is, and end the stream.
+//
+// A message which is framed correctly but can not be parsed is returned along
+// with a *ParseError, as by ParseBuffer. Decoding may continue with the next
+// message.
+func (d *StreamDecoder) Decode() (Message, error) {
+	for {
+		bs, err := d.r.Peek(2)
+		if err == io.EOF && len(bs) > 0 {
+			return Message{}, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return Message{}, err
+		}
+
+		var n int
+		switch binary.BigEndian.Uint16(bs) {
+		case ipfixVersion:
+			n, err = frameIPFIX(d.r)
+		case nfv9Version:
+			n, err = d.s.frameNFv9(d.r, d.exporter)
+		default:
+			err = ErrProtocol
+		}
+		if err == ErrProtocol {
+			// Not a message, resynchronise
+			if debug {
+				dl.Println("stream decoder: skipping byte")
+			}
+			d.r.Discard(1)
+			d.skipped++
+			continue
+		} else if err == io.EOF {
+			return Message{}, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return Message{}, err
+		}
+
+		bs, _ = d.r.Peek(n)
+		msg, err := d.s.parseBuffer(bs, bs, d.exporter)
+		d.r.Discard(n)
+		return msg, err
+	}
+}
+
+// Skipped returns the number of bytes skipped so far because they did not
+// belong to a message.
+func (d *StreamDecoder) Skipped() int64 {
+	return d.skipped
+}
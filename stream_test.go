@@ -0,0 +1,141 @@
+package ipfix
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+)
+
+var (
+	// IPFIX template 256 with one data record
+	streamIPFIX, _ = hex.DecodeString("000a00345685b3700000000000000001000200140100000300080004000c00040002000401000010c0a800c9c0a8000100000001")
+	// NFv9 options template 257 with one options data record
+	streamNFv9Options, _ = hex.DecodeString("00090002198afac45defcbd800103e570000000000010018010100040008000100040022000400230004000001010010000000000000006400000001")
+	// NFv9 data flowset for the unknown template 256
+	streamNFv9Unknown, _ = hex.DecodeString("0009000100000000000000000000000100000000010000080102030400090000198afac45defcbd80000000000000001")
+)
+
+func TestParseBufferAllNFv9(t *testing.T) {
+	buf := append(append(append([]byte(nil), streamNFv9Options...), streamIPFIX...), streamNFv9Options...)
+	p := NewSession()
+	msgs, err := p.ParseBufferAll(buf)
+	if err != nil {
+		t.Fatal("ParseBufferAll failed", err)
+	}
+	if len(msgs) != 3 {
+		t.Fatal("Incorrect number of messages", len(msgs))
+	}
+	for i, want := range []uint16{9, 10, 9} {
+		if msgs[i].Header.Version != want {
+			t.Errorf("Incorrect version %d of message %d", msgs[i].Header.Version, i)
+		}
+	}
+	if len(msgs[2].OptionsDataRecords) != 1 || len(msgs[1].DataRecords) != 1 {
+		t.Error("Incorrect records", msgs)
+	}
+
+	// The length of an IPFIX message can not be shorter than its header
+	bs := append([]byte(nil), streamIPFIX...)
+	bs[3] = 4
+	msgs, err = p.ParseBufferAll(append(append([]byte(nil), streamNFv9Options...), bs...))
+	if len(msgs) != 1 {
+		t.Error("Incorrect number of messages", len(msgs))
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrProtocol) || pe.Offset != len(streamNFv9Options) {
+		t.Error("Expected ErrProtocol at the second message, got", err)
+	}
+
+	if _, err = p.ParseBufferAll(streamIPFIX[:len(streamIPFIX)-1]); !errors.Is(err, ErrRead) {
+		t.Error("Expected ErrRead, got", err)
+	}
+}
+
+func TestStreamDecoder(t *testing.T) {
+	var stream []byte
+	stream = append(stream, 0xde, 0xad, 0xbe, 0xef)
+	stream = append(stream, streamIPFIX...)
+	stream = append(stream, 0x00)
+	stream = append(stream, streamNFv9Options...)
+	stream = append(stream, streamNFv9Unknown...)
+
+	d := NewStreamDecoder(NewSession(), bytes.NewReader(stream))
+	var msgs []Message
+	for {
+		msg, err := d.Decode()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal("Decode failed", err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if len(msgs) != 4 {
+		t.Fatal("Incorrect number of messages", len(msgs))
+	}
+	if len(msgs[0].DataRecords) != 1 || len(msgs[1].OptionsDataRecords) != 1 {
+		t.Error("Incorrect records", msgs)
+	}
+	// The data flowset with the unknown template is followed by a header-only packet
+	if msgs[2].Header.Length != 1 || msgs[3].Header.Length != 0 || msgs[3].Header.DomainID != 1 {
+		t.Error("Incorrect framing of unknown records", msgs[2].Header, msgs[3].Header)
+	}
+	if n := d.Skipped(); n != 5 {
+		t.Error("Incorrect number of skipped bytes", n)
+	}
+}
+
+func TestStreamDecoderTruncated(t *testing.T) {
+	d := NewStreamDecoder(NewSession(), bytes.NewReader(streamIPFIX[:30]))
+	if _, err := d.Decode(); err != io.ErrUnexpectedEOF {
+		t.Error("Expected io.ErrUnexpectedEOF, got", err)
+	}
+}
+
+// chunkReader returns its input a few bytes at a time, as a slow TCP
+// connection would.
+type chunkReader struct {
+	bs []byte
+	n  int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.bs) == 0 {
+		return 0, io.EOF
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	n := copy(p, r.bs)
+	r.bs = r.bs[n:]
+	return n, nil
+}
+
+func TestStreamDecoderUnknownTemplateChunked(t *testing.T) {
+	// Two data flowsets for the unknown template 256
+	var stream []byte
+	stream = append(stream, streamNFv9Unknown[:28]...)
+	stream[3] = 2
+	stream = append(stream, streamNFv9Unknown[20:28]...)
+	stream = append(stream, streamNFv9Options...)
+
+	for _, n := range []int{1, 4, 8, len(stream)} {
+		d := NewStreamDecoder(NewSession(), &chunkReader{bs: stream, n: n})
+		var msgs []Message
+		for {
+			msg, err := d.Decode()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal("Decode failed", err)
+			}
+			msgs = append(msgs, msg)
+		}
+		if len(msgs) != 2 || len(msgs[1].OptionsDataRecords) != 1 || d.Skipped() != 0 {
+			t.Errorf("Incorrect framing with %d byte reads: %d messages, %d bytes skipped", n, len(msgs), d.Skipped())
+		}
+	}
+}
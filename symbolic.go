@@ -0,0 +1,296 @@
+package ipfix
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WithSymbolicValues makes the Interpreter decode enumerated and bitfield
+// information elements, such as protocolIdentifier and tcpControlBits, into
+// EnumValue, FlagsValue and ICMPTypeCode values using the Symbols of their
+// DictionaryEntry, which the builtin dictionaries take from the IANA
+// sub-registries. They print as their symbolic names and keep the numeric
+// value. Other fields are decoded as usual.
+func WithSymbolicValues() InterpreterOption {
+	return func(i *Interpreter) {
+		i.symbolic = true
+	}
+}
+
+// An EnumValue is the value of an enumerated information element, such as
+// protocolIdentifier or flowEndReason.
+type EnumValue struct {
+	Value uint64
+	Name  string // empty if the value is not assigned
+}
+
+func (v EnumValue) String() string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return strconv.FormatUint(v.Value, 10)
+}
+
+// A FlagsValue is the value of a bitfield information element, such as
+// tcpControlBits.
+type FlagsValue struct {
+	Value uint64
+	Flags []string // names of the bits set, from the least significant
+	named uint64   // the bits with a name
+}
+
+// String returns the names of the bits set separated by "|", followed by the
+// unnamed bits in hex if there are any.
+func (v FlagsValue) String() string {
+	s := strings.Join(v.Flags, "|")
+	if rest := v.Value &^ v.named; rest != 0 || s == "" {
+		if s != "" {
+			s += "|"
+		}
+		s += fmt.Sprintf("%#x", rest)
+	}
+	return s
+}
+
+// An ICMPTypeCode is the value of icmpTypeCodeIPv4, icmpTypeCodeIPv6 or the
+// Netflow v9 ICMP_TYPE, which carry the ICMP type in the upper byte and the
+// code in the lower byte.
+type ICMPTypeCode struct {
+	Value uint16
+	Type  uint8
+	Code  uint8
+	Name  string // of the type, empty if it is not assigned
+}
+
+// String returns the type and code separated by "/", as in "echo-request/0".
+func (v ICMPTypeCode) String() string {
+	if v.Name != "" {
+		return v.Name + "/" + strconv.Itoa(int(v.Code))
+	}
+	return strconv.Itoa(int(v.Type)) + "/" + strconv.Itoa(int(v.Code))
+}
+
+// Symbols are the names of the values of an enumerated or bitfield
+// information element, see NewEnumSymbols and NewFlagSymbols. They can not
+// be modified once created, so the entries of a Dictionary may share them.
+type Symbols struct {
+	names   map[uint64]string
+	flags   []string
+	decoder symbolDecoder // for the builtin elements with a layout of their own
+}
+
+// NewEnumSymbols returns the Symbols of an enumeration, whose values are
+// decoded as EnumValues named by names.
+func NewEnumSymbols(names map[uint64]string) *Symbols {
+	s := &Symbols{names: make(map[uint64]string, len(names))}
+	for v, name := range names {
+		s.names[v] = name
+	}
+	return s
+}
+
+// NewFlagSymbols returns the Symbols of a bitfield, whose values are decoded
+// as FlagsValues. names holds the names of the bits from the least
+// significant, an empty name leaving the bit unnamed.
+func NewFlagSymbols(names []string) *Symbols {
+	return &Symbols{flags: append([]string{}, names...)}
+}
+
+// A symbolDecoder decodes the numeric value of an information element into a
+// symbolic value.
+type symbolDecoder func(uint64) interface{}
+
+// Value returns the symbolic value of v, an EnumValue, FlagsValue or
+// ICMPTypeCode.
+func (s *Symbols) Value(v uint64) interface{} {
+	if s.decoder != nil {
+		return s.decoder(v)
+	}
+	if s.flags == nil {
+		return EnumValue{Value: v, Name: s.names[v]}
+	}
+	fv := FlagsValue{Value: v}
+	for i, name := range s.flags {
+		if bit := uint64(1) << uint(i); name != "" {
+			fv.named |= bit
+			if v&bit != 0 {
+				fv.Flags = append(fv.Flags, name)
+			}
+		}
+	}
+	return fv
+}
+
+func icmpDecoder(names map[uint8]string) symbolDecoder {
+	return func(v uint64) interface{} {
+		t, c := uint8(v>>8), uint8(v)
+		return ICMPTypeCode{Value: uint16(v), Type: t, Code: c, Name: names[t]}
+	}
+}
+
+// symbolicValue returns the symbolic value of an interpreted field, or the
+// value itself if it is not numeric.
+func symbolicValue(s *Symbols, value interface{}) interface{} {
+	switch v := value.(type) {
+	case uint8:
+		return s.Value(uint64(v))
+	case uint16:
+		return s.Value(uint64(v))
+	case uint32:
+		return s.Value(uint64(v))
+	case uint64:
+		return s.Value(v)
+	}
+	return value
+}
+
+// The IANA sub-registries of the information elements below.
+
+var protocolNames = map[uint64]string{
+	0: "hopopt", 1: "icmp", 2: "igmp", 4: "ipv4", 6: "tcp", 8: "egp", 9: "igp",
+	17: "udp", 27: "rdp", 33: "dccp", 41: "ipv6", 43: "ipv6-route",
+	44: "ipv6-frag", 46: "rsvp", 47: "gre", 50: "esp", 51: "ah",
+	58: "ipv6-icmp", 59: "ipv6-nonxt", 60: "ipv6-opts", 88: "eigrp",
+	89: "ospf", 94: "ipip", 103: "pim", 108: "ipcomp", 112: "vrrp",
+	115: "l2tp", 124: "isis", 132: "sctp", 136: "udplite", 137: "mpls-in-ip",
+}
+
+// tcpControlBits, RFC 7125
+var tcpFlagNames = []string{"FIN", "SYN", "RST", "PSH", "ACK", "URG", "ECE", "CWR", "NS"}
+
+// forwardingStatusDecoder decodes forwardingStatus (RFC 7270 section 4.12),
+// which holds the status in the upper two bits and the reason code in the
+// lower six. Unassigned reason codes are named by number.
+func forwardingStatusDecoder(v uint64) interface{} {
+	ev := EnumValue{Value: v, Name: forwardingStatusNames[v]}
+	if status := forwardingStatusNames[v&0xc0]; ev.Name == "" && v < 0x100 && v >= 0x40 {
+		ev.Name = status[:strings.IndexByte(status, '/')+1] + strconv.Itoa(int(v&0x3f))
+	}
+	return ev
+}
+
+var forwardingStatusNames = map[uint64]string{
+	0:   "Unknown",
+	64:  "Forwarded/Unknown",
+	65:  "Forwarded/Fragmented",
+	66:  "Forwarded/Not Fragmented",
+	128: "Dropped/Unknown",
+	129: "Dropped/ACL deny",
+	130: "Dropped/ACL drop",
+	131: "Dropped/Unroutable",
+	132: "Dropped/Adjacency",
+	133: "Dropped/Fragmentation and DF set",
+	134: "Dropped/Bad header checksum",
+	135: "Dropped/Bad total Length",
+	136: "Dropped/Bad header length",
+	137: "Dropped/bad TTL",
+	138: "Dropped/Policer",
+	139: "Dropped/WRED",
+	140: "Dropped/RPF",
+	141: "Dropped/For us",
+	142: "Dropped/Bad output interface",
+	143: "Dropped/Hardware",
+	192: "Consumed/Unknown",
+	193: "Consumed/Punt Adjacency",
+	194: "Consumed/Incomplete Adjacency",
+	195: "Consumed/For us",
+}
+
+var flowEndReasonNames = map[uint64]string{
+	1: "idle timeout",
+	2: "active timeout",
+	3: "end of Flow detected",
+	4: "forced end",
+	5: "lack of resources",
+}
+
+// natEvent, RFC 8158 section 5
+var natEventNames = map[uint64]string{
+	1:  "NAT44 session create",
+	2:  "NAT44 session delete",
+	3:  "NAT64 session create",
+	4:  "NAT64 session delete",
+	5:  "NAT44 BIB create",
+	6:  "NAT44 BIB delete",
+	7:  "NAT64 BIB create",
+	8:  "NAT64 BIB delete",
+	9:  "NAT Addresses exhausted",
+	10: "NAT44 Session Limit Exceeded",
+	11: "NAT64 Session Limit Exceeded",
+	12: "NAT44 BIB Limit Exceeded",
+	13: "NAT64 BIB Limit Exceeded",
+	14: "NAT Ports exhausted",
+	15: "NAT Quota Exceeded",
+	16: "NAT Address binding create",
+	17: "NAT Address binding delete",
+	18: "NAT port block allocation",
+	19: "NAT port block de-allocation",
+	20: "Threshold Reached",
+}
+
+var firewallEventNames = map[uint64]string{
+	0: "Ignore",
+	1: "Flow Created",
+	2: "Flow Deleted",
+	3: "Flow Denied",
+	4: "Flow Alert",
+	5: "Flow Update",
+}
+
+var flowDirectionNames = map[uint64]string{
+	0: "ingress",
+	1: "egress",
+}
+
+var icmpv4TypeNames = map[uint8]string{
+	0:  "echo-reply",
+	3:  "destination-unreachable",
+	4:  "source-quench",
+	5:  "redirect",
+	8:  "echo-request",
+	9:  "router-advertisement",
+	10: "router-solicitation",
+	11: "time-exceeded",
+	12: "parameter-problem",
+	13: "timestamp",
+	14: "timestamp-reply",
+}
+
+var icmpv6TypeNames = map[uint8]string{
+	1:   "destination-unreachable",
+	2:   "packet-too-big",
+	3:   "time-exceeded",
+	4:   "parameter-problem",
+	128: "echo-request",
+	129: "echo-reply",
+	130: "multicast-listener-query",
+	131: "multicast-listener-report",
+	132: "multicast-listener-done",
+	133: "router-solicitation",
+	134: "router-advertisement",
+	135: "neighbor-solicitation",
+	136: "neighbor-advertisement",
+	137: "redirect",
+}
+
+// The Symbols of the builtin dictionaries, from the IANA sub-registries. They
+// are kept apart from the generated dictionaries, see Dictionary.
+var builtinIpfixSymbols = map[dictionaryKey]*Symbols{
+	{0, 4}:   {names: protocolNames},                  // protocolIdentifier
+	{0, 6}:   {flags: tcpFlagNames},                   // tcpControlBits
+	{0, 32}:  {decoder: icmpDecoder(icmpv4TypeNames)}, // icmpTypeCodeIPv4
+	{0, 61}:  {names: flowDirectionNames},             // flowDirection
+	{0, 89}:  {decoder: forwardingStatusDecoder},      // forwardingStatus
+	{0, 136}: {names: flowEndReasonNames},             // flowEndReason
+	{0, 139}: {decoder: icmpDecoder(icmpv6TypeNames)}, // icmpTypeCodeIPv6
+	{0, 230}: {names: natEventNames},                  // natEvent
+	{0, 233}: {names: firewallEventNames},             // firewallEvent
+}
+
+var builtinNetflowV9Symbols = map[dictionaryKey]*Symbols{
+	{0, 4}:  builtinIpfixSymbols[dictionaryKey{0, 4}],  // PROTOCOL
+	{0, 6}:  builtinIpfixSymbols[dictionaryKey{0, 6}],  // TCP_FLAGS
+	{0, 32}: builtinIpfixSymbols[dictionaryKey{0, 32}], // ICMP_TYPE
+	{0, 61}: builtinIpfixSymbols[dictionaryKey{0, 61}], // DIRECTION
+}
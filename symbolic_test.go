@@ -0,0 +1,110 @@
+package ipfix
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSymbolicValues(t *testing.T) {
+	tpl := []TemplateFieldSpecifier{
+		{FieldID: 4, Length: 1},   // protocolIdentifier
+		{FieldID: 6, Length: 2},   // tcpControlBits
+		{FieldID: 89, Length: 1},  // forwardingStatus
+		{FieldID: 136, Length: 1}, // flowEndReason
+		{FieldID: 32, Length: 2},  // icmpTypeCodeIPv4
+		{FieldID: 233, Length: 1}, // firewallEvent
+		{FieldID: 7, Length: 2},   // sourceTransportPort
+	}
+	s := NewSession()
+	s.LoadTemplateRecords([]TemplateRecord{{TemplateID: 256, FieldSpecifiers: tpl}})
+	rec := DataRecord{
+		TemplateID: 256,
+		Fields:     [][]byte{{6}, {0x10, 0x12}, {0x40}, {2}, {8, 0}, {3}, {0, 80}},
+	}
+
+	fields := NewInterpreter(s).Interpret(rec)
+	if v := fields[0].Value; v != uint8(6) {
+		t.Error("Symbolic value without WithSymbolicValues", v)
+	}
+
+	fields = NewInterpreter(s, WithSymbolicValues()).Interpret(rec)
+	for i, want := range []string{"tcp", "SYN|ACK|0x1000", "Forwarded/Unknown", "active timeout", "echo-request/0", "Flow Denied", "80"} {
+		if got := fmt.Sprint(fields[i].Value); got != want {
+			t.Errorf("%s: %q != %q", fields[i].Name, got, want)
+		}
+	}
+	if v := fields[0].Value.(EnumValue); v.Value != 6 {
+		t.Error("Incorrect numeric value", v.Value)
+	}
+	if v := fields[1].Value.(FlagsValue); v.Value != 0x1012 || len(v.Flags) != 2 {
+		t.Error("Incorrect flags", v)
+	}
+	if v := fields[4].Value.(ICMPTypeCode); v.Type != 8 || v.Code != 0 || v.Value != 0x800 {
+		t.Error("Incorrect ICMP type and code", v)
+	}
+	if v := fields[6].Value; v != uint16(80) {
+		t.Error("Incorrect plain value", v)
+	}
+}
+
+func TestSymbolicStrings(t *testing.T) {
+	sym := func(id uint16) *Symbols {
+		return builtinIpfixSymbols[dictionaryKey{0, id}]
+	}
+	for _, c := range []struct {
+		value interface{}
+		want  string
+	}{
+		{sym(4).Value(253), "253"},
+		{sym(6).Value(0), "0x0"},
+		{sym(6).Value(0x11), "FIN|ACK"},
+		{sym(89).Value(0x89), "Dropped/bad TTL"},
+		{sym(89).Value(0xa0), "Dropped/32"},
+		{sym(139).Value(0x0104), "destination-unreachable/4"},
+		{sym(32).Value(0xfe01), "254/1"},
+	} {
+		if got := fmt.Sprint(c.value); got != c.want {
+			t.Errorf("%q != %q", got, c.want)
+		}
+	}
+}
+
+func TestSymbolicUserEntries(t *testing.T) {
+	names := map[uint64]string{0: "permit", 1: "deny"}
+	flags := []string{"inbound", "", "logged"}
+	es := []DictionaryEntry{
+		{Name: "someVendorAction", FieldID: 44, EnterpriseID: 123456, Type: Uint8, Symbols: NewEnumSymbols(names)},
+		{Name: "someVendorFlags", FieldID: 45, EnterpriseID: 123456, Type: Uint8, Symbols: NewFlagSymbols(flags)},
+	}
+	// The Symbols keep their own copy of the names
+	names[1] = "changed"
+	flags[0] = "changed"
+
+	s := NewSession()
+	s.LoadTemplateRecords([]TemplateRecord{{
+		TemplateID: 256,
+		FieldSpecifiers: []TemplateFieldSpecifier{
+			{EnterpriseID: 123456, FieldID: 44, Length: 1},
+			{EnterpriseID: 123456, FieldID: 45, Length: 1},
+		},
+	}})
+	i := NewInterpreter(s, WithSymbolicValues())
+	i.AddDictionaryEntries(es)
+
+	fields := i.Interpret(DataRecord{TemplateID: 256, Fields: [][]byte{{1}, {0x07}}})
+	for j, want := range []string{"deny", "inbound|logged|0x2"} {
+		if got := fmt.Sprint(fields[j].Value); got != want {
+			t.Errorf("%s: %q != %q", fields[j].Name, got, want)
+		}
+	}
+
+	// Entries with the same names are identical
+	same := []DictionaryEntry{es[0], es[1]}
+	same[0].Symbols = NewEnumSymbols(map[uint64]string{0: "permit", 1: "deny"})
+	if conflicts := i.AddDictionaryEntries(same); len(conflicts) != 0 {
+		t.Error("Conflicts with identical entries", conflicts)
+	}
+	if e, _ := i.Dictionary().Lookup(0, 4); e.Symbols == nil {
+		t.Error("Builtin symbols lost by the added entries")
+	}
+}
@@ -0,0 +1,94 @@
+package ipfix
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"time"
+)
+
+// ServeTLS works like Serve, but accepts IPFIX over TLS connections (RFC 7011
+// section 11). To authenticate the exporters, set ClientAuth and ClientCAs in
+// config. The leaf certificate presented by an exporter is set as
+// PeerCertificate on every message received from it, so the handler can tie
+// the records to an authenticated identity. To scope the templates by that
+// identity as well, see WithPeerIdentity. Connections failing the handshake,
+// or not completing it in time (see WithHandshakeTimeout), are reported to
+// the ErrorHandler and closed.
+func (c *Collector) ServeTLS(l net.Listener, config *tls.Config) error {
+	return c.Serve(tls.NewListener(l, config))
+}
+
+// WithPeerIdentity makes the Collector scope the templates of IPFIX over TLS
+// connections by the identity id returns for the certificate of the exporter,
+// such as its subject common name, instead of the remote address. The
+// identity is the Exporter of the TemplateScope of the records, so they can
+// be filtered by it. Connections sharing an identity share their templates,
+// which are forgotten when any of them closes. Connections without a
+// certificate, or for which id returns "", are scoped by their address.
+func WithPeerIdentity(id func(*x509.Certificate) string) CollectorOption {
+	return func(c *Collector) {
+		c.peerIdentity = id
+	}
+}
+
+// A peerAddr is the address of an exporter identified by its certificate,
+// see WithPeerIdentity.
+type peerAddr string
+
+func (a peerAddr) Network() string { return "tls" }
+func (a peerAddr) String() string  { return string(a) }
+
+// scopeAddr returns the address scoping the templates of a connection from
+// addr with the peer certificate cert.
+func (c *Collector) scopeAddr(addr net.Addr, cert *x509.Certificate) net.Addr {
+	if cert == nil || c.peerIdentity == nil {
+		return addr
+	}
+	if id := c.peerIdentity(cert); id != "" {
+		return peerAddr(id)
+	}
+	return addr
+}
+
+// WithHandshakeTimeout sets the time an exporter has to complete the TLS
+// handshake, see ServeTLS. The default is 10 seconds.
+func WithHandshakeTimeout(d time.Duration) CollectorOption {
+	return func(c *Collector) {
+		c.handshakeTimeout = d
+	}
+}
+
+// handshake completes the TLS handshake of conn, if it is a TLS connection,
+// within timeout, and returns the leaf certificate of the peer. The
+// certificate is nil if the peer did not present one.
+func handshake(conn net.Conn, timeout time.Duration) (*x509.Certificate, error) {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil, nil
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := tc.Handshake(); err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	if certs := tc.ConnectionState().PeerCertificates; len(certs) > 0 {
+		return certs[0], nil
+	}
+	return nil, nil
+}
+
+// DialTLS connects to an IPFIX over TLS collector, completes the handshake
+// and returns an Exporter writing to the connection. To authenticate to a
+// collector requiring client certificates, set Certificates in config. The
+// Exporter is configured by opts, but its messages may be up to 65535 bytes
+// long unless WithMTU is given, as they are not sent as datagrams. Flush the
+// Exporter before closing the returned connection.
+func DialTLS(network, address string, config *tls.Config, opts ...ExporterOption) (*Exporter, *tls.Conn, error) {
+	conn, err := tls.Dial(network, address, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts = append([]ExporterOption{WithMTU(maxMessageLength)}, opts...)
+	return NewExporter(conn, opts...), conn, nil
+}
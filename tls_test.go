@@ -0,0 +1,222 @@
+package ipfix
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// newCert returns a certificate for cn signed by parent, or a self-signed CA
+// certificate if parent is nil.
+func newCert(t *testing.T, cn string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, interface{}(key)
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestCollectorTLS(t *testing.T) {
+	ca := newCert(t, "ca", nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	server := newCert(t, "collector", &ca)
+	client := newCert(t, "exporter-1", &ca)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := make(chan Message, 1)
+	errs := make(chan error, 1)
+	c := NewCollector(NewSession(), func(msg Message, addr net.Addr) {
+		msgs <- msg
+	}, WithErrorHandler(func(addr net.Addr, err error) {
+		errs <- err
+	}))
+	served := make(chan error, 1)
+	go func() {
+		served <- c.ServeTLS(l, &tls.Config{
+			Certificates: []tls.Certificate{server},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+		})
+	}()
+
+	e, conn, err := DialTLS("tcp", l.Addr().String(), &tls.Config{
+		Certificates: []tls.Certificate{client},
+		RootCAs:      pool,
+	})
+	if err != nil {
+		t.Fatal("DialTLS failed", err)
+	}
+	if err := e.AddTemplate(1, exportTemplate); err != nil {
+		t.Fatal("AddTemplate failed", err)
+	}
+	if err := e.Add(1, exportRecord(1)); err != nil {
+		t.Fatal("Add failed", err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal("Flush failed", err)
+	}
+	select {
+	case msg := <-msgs:
+		if len(msg.DataRecords) != 1 {
+			t.Error("Incorrect number of data records", len(msg.DataRecords))
+		}
+		if msg.PeerCertificate == nil || msg.PeerCertificate.Subject.CommonName != "exporter-1" {
+			t.Error("Incorrect peer certificate", msg.PeerCertificate)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for message")
+	}
+	conn.Close()
+
+	// Exporters without a certificate are rejected
+	conn, err = tls.Dial("tcp", l.Addr().String(), &tls.Config{RootCAs: pool})
+	if err == nil {
+		// TLS 1.3 reports the rejection on the first read
+		conn.Write(streamIPFIX)
+		conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handshake error not reported")
+	}
+	select {
+	case msg := <-msgs:
+		t.Error("Message from unauthenticated exporter", msg)
+	default:
+	}
+
+	c.Close()
+	if err := <-served; err != ErrCollectorClosed {
+		t.Error("Expected ErrCollectorClosed, got", err)
+	}
+}
+
+func TestCollectorTLSPeerIdentity(t *testing.T) {
+	ca := newCert(t, "ca", nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	server := newCert(t, "collector", &ca)
+	client := newCert(t, "exporter-1", &ca)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSession()
+	msgs := make(chan Message, 1)
+	c := NewCollector(s, func(msg Message, addr net.Addr) {
+		msgs <- msg
+	}, WithPeerIdentity(func(cert *x509.Certificate) string {
+		return cert.Subject.CommonName
+	}))
+	go c.ServeTLS(l, &tls.Config{
+		Certificates: []tls.Certificate{server},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})
+
+	e, conn, err := DialTLS("tcp", l.Addr().String(), &tls.Config{
+		Certificates: []tls.Certificate{client},
+		RootCAs:      pool,
+	})
+	if err != nil {
+		t.Fatal("DialTLS failed", err)
+	}
+	if err := e.AddTemplate(1, exportTemplate); err != nil {
+		t.Fatal("AddTemplate failed", err)
+	}
+	if err := e.Add(1, exportRecord(1)); err != nil {
+		t.Fatal("Add failed", err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal("Flush failed", err)
+	}
+	scope := TemplateScope{Exporter: "exporter-1", DomainID: 1}
+	select {
+	case msg := <-msgs:
+		if len(msg.DataRecords) != 1 || msg.DataRecords[0].Scope != scope {
+			t.Error("Incorrect records", msg.DataRecords)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timeout waiting for message")
+	}
+	if s.lookupUnaliasedTemplateFieldSpecifiers(scope, 256) == nil {
+		t.Error("Template not scoped by the peer identity")
+	}
+
+	conn.Close()
+	c.Close()
+	if s.lookupUnaliasedTemplateFieldSpecifiers(scope, 256) != nil {
+		t.Error("Template not forgotten with the connection")
+	}
+}
+
+func TestCollectorTLSHandshakeTimeout(t *testing.T) {
+	ca := newCert(t, "ca", nil)
+	server := newCert(t, "collector", &ca)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	c := NewCollector(NewSession(), func(msg Message, addr net.Addr) {},
+		WithHandshakeTimeout(50*time.Millisecond),
+		WithErrorHandler(func(addr net.Addr, err error) {
+			errs <- err
+		}))
+	defer c.Close()
+	go c.ServeTLS(l, &tls.Config{Certificates: []tls.Certificate{server}})
+
+	// The client never starts the handshake
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	select {
+	case err := <-errs:
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+			t.Error("Expected a timeout, got", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handshake did not time out")
+	}
+}
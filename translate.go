@@ -0,0 +1,217 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+// The Netflow v9 field types which need more than a change of version to be
+// expressed in IPFIX. The field types below 128 are otherwise the same as
+// the IPFIX information elements with the same ID (RFC 7012 section 4).
+const (
+	nfv9LastSwitched  = 21
+	nfv9FirstSwitched = 22
+)
+
+// nfv9Fields maps Netflow v9 field types onto IPFIX information elements,
+// where the IDs differ.
+var nfv9Fields = map[uint16]TemplateFieldSpecifier{
+	nfv9LastSwitched:  {FieldID: 153, Length: 8}, // flowEndMilliseconds
+	nfv9FirstSwitched: {FieldID: 152, Length: 8}, // flowStartMilliseconds
+}
+
+// nfv9ScopeFields maps the scope field types of Netflow v9 options templates
+// (RFC 3954 section 6.1) onto IPFIX information elements.
+var nfv9ScopeFields = map[uint16]uint16{
+	1: 144, // System: exportingProcessId
+	2: 10,  // Interface: ingressInterface
+	3: 141, // Line Card: lineCardId
+	4: 143, // Cache: meteringProcessId
+	5: 145, // Template: templateId
+}
+
+// ciscoEnterpriseID is the private enterprise number of Cisco, which defined
+// the Netflow v9 field types above 32767.
+const ciscoEnterpriseID = 9
+
+// A Translator converts Netflow v9 messages into equivalent IPFIX messages,
+// so that a pipeline only needs to handle IPFIX whatever the routers send.
+//
+// Template fields are mapped onto the IPFIX information elements with the
+// same ID, except FIRST_SWITCHED and LAST_SWITCHED. These hold times relative
+// to the SysUptime of the exporter, and are converted to the absolute
+// flowStartMilliseconds and flowEndMilliseconds using the message header.
+// The scope fields of options templates are mapped onto exportingProcessId,
+// ingressInterface, lineCardId, meteringProcessId and templateId, and
+// vendor field types above 32767 onto enterprise-specific elements of
+// enterprise 9 (Cisco).
+//
+// IPFIX sequence numbers count data records rather than messages, so the
+// Translator numbers the messages of each exporter and observation domain
+// itself. A Translator is goroutine safe.
+type Translator struct {
+	s *Session
+
+	mut       sync.Mutex
+	sequences map[TemplateScope]uint32
+	templates map[templateKey][]TemplateFieldSpecifier // translated
+}
+
+// NewTranslator returns a Translator for the messages parsed by s, which
+// holds the templates of their data records.
+func NewTranslator(s *Session) *Translator {
+	return &Translator{
+		s:         s,
+		sequences: make(map[TemplateScope]uint32),
+		templates: make(map[templateKey][]TemplateFieldSpecifier),
+	}
+}
+
+// Translate returns the IPFIX message equivalent to the Netflow v9 message
+// msg. It fails with ErrVersion for other versions, and ErrUnknownTemplate if
+// the template of a data record is not known to the Session. The message
+// carries only the templates of msg, so use the Marshal method of the
+// Translator to encode messages with only data records.
+func (t *Translator) Translate(msg Message) (Message, error) {
+	if msg.Header.Version != nfv9Version {
+		return Message{}, ErrVersion
+	}
+
+	m := Message{
+		Header: MessageHeader{
+			Version:    ipfixVersion,
+			ExportTime: msg.Header.ExportTime,
+			DomainID:   msg.Header.DomainID,
+		},
+		Errors:          msg.Errors,
+		PeerCertificate: msg.PeerCertificate,
+	}
+	translated := make(map[templateKey][]TemplateFieldSpecifier)
+	for _, tr := range msg.TemplateRecords {
+		tr.FieldSpecifiers = translateFieldSpecifiers(tr.FieldSpecifiers, 0)
+		translated[templateKey{tr.Scope, tr.TemplateID}] = tr.FieldSpecifiers
+		m.TemplateRecords = append(m.TemplateRecords, tr)
+	}
+	for _, otr := range msg.OptionsTemplateRecords {
+		otr.FieldSpecifiers = translateFieldSpecifiers(otr.FieldSpecifiers, int(otr.ScopeFieldCount))
+		translated[templateKey{otr.Scope, otr.TemplateID}] = otr.FieldSpecifiers
+		m.OptionsTemplateRecords = append(m.OptionsTemplateRecords, otr)
+	}
+
+	for _, dr := range msg.DataRecords {
+		tpl := t.s.lookupUnaliasedTemplateFieldSpecifiers(dr.Scope, dr.TemplateID)
+		if tpl == nil {
+			return Message{}, ErrUnknownTemplate
+		}
+		dr.Fields = translateFields(msg.Header, tpl, dr.Fields)
+		if key := (templateKey{dr.Scope, dr.TemplateID}); translated[key] == nil {
+			translated[key] = translateFieldSpecifiers(tpl, 0)
+		}
+		m.DataRecords = append(m.DataRecords, dr)
+	}
+	for _, odr := range msg.OptionsDataRecords {
+		tpl := t.s.lookupUnaliasedTemplateFieldSpecifiers(odr.Scope, odr.TemplateID)
+		if len(tpl) < len(odr.ScopeFields) {
+			return Message{}, ErrUnknownTemplate
+		}
+		odr.Fields = translateFields(msg.Header, tpl[len(odr.ScopeFields):], odr.Fields)
+		if key := (templateKey{odr.Scope, odr.TemplateID}); translated[key] == nil {
+			translated[key] = translateFieldSpecifiers(tpl, len(odr.ScopeFields))
+		}
+		m.OptionsDataRecords = append(m.OptionsDataRecords, odr)
+	}
+
+	scope := messageScope(msg)
+	t.mut.Lock()
+	for key, fs := range translated {
+		t.templates[key] = fs
+	}
+	m.Header.SequenceNumber = t.sequences[scope]
+	t.sequences[scope] += uint32(len(m.DataRecords) + len(m.OptionsDataRecords))
+	t.mut.Unlock()
+	return m, nil
+}
+
+// Marshal encodes a message returned by Translate. The templates of its data
+// records must have been translated before, with this or an earlier message.
+func (t *Translator) Marshal(m Message) ([]byte, error) {
+	return m.marshal(t.lookup)
+}
+
+func (t *Translator) lookup(scope TemplateScope, tid uint16) []TemplateFieldSpecifier {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.templates[templateKey{scope, tid}]
+}
+
+// messageScope returns the scope of the templates and records of a parsed
+// message.
+func messageScope(msg Message) TemplateScope {
+	switch {
+	case len(msg.DataRecords) > 0:
+		return msg.DataRecords[0].Scope
+	case len(msg.OptionsDataRecords) > 0:
+		return msg.OptionsDataRecords[0].Scope
+	case len(msg.TemplateRecords) > 0:
+		return msg.TemplateRecords[0].Scope
+	case len(msg.OptionsTemplateRecords) > 0:
+		return msg.OptionsTemplateRecords[0].Scope
+	}
+	return TemplateScope{DomainID: msg.Header.DomainID}
+}
+
+// translateFieldSpecifiers maps Netflow v9 field specifiers onto IPFIX. The
+// first scopeCount of them are options template scope fields.
+func translateFieldSpecifiers(fs []TemplateFieldSpecifier, scopeCount int) []TemplateFieldSpecifier {
+	ipfs := make([]TemplateFieldSpecifier, len(fs))
+	for i, f := range fs {
+		switch {
+		case i < scopeCount:
+			if id, ok := nfv9ScopeFields[f.FieldID]; ok {
+				f.FieldID = id
+			}
+		case f.EnterpriseID != 0:
+		case f.FieldID >= 0x8000:
+			f.EnterpriseID = ciscoEnterpriseID
+			f.FieldID -= 0x8000
+		default:
+			if ipf, ok := nfv9Fields[f.FieldID]; ok {
+				f = ipf
+			}
+		}
+		ipfs[i] = f
+	}
+	return ipfs
+}
+
+// translateFields converts the fields of a Netflow v9 data record which are
+// encoded differently in IPFIX. The other fields are shared with the
+// original record.
+func translateFields(hdr MessageHeader, tpl []TemplateFieldSpecifier, fields [][]byte) [][]byte {
+	var ipfields [][]byte
+	for i, f := range tpl {
+		if i >= len(fields) {
+			break
+		}
+		if f.EnterpriseID != 0 || (f.FieldID != nfv9FirstSwitched && f.FieldID != nfv9LastSwitched) {
+			continue
+		}
+		if ipfields == nil {
+			ipfields = append([][]byte{}, fields...)
+		}
+		ipfields[i] = make([]byte, 8)
+		binary.BigEndian.PutUint64(ipfields[i], absoluteMilliseconds(hdr, uint32(number(fields[i]))))
+	}
+	if ipfields == nil {
+		return fields
+	}
+	return ipfields
+}
+
+// absoluteMilliseconds converts a time in milliseconds of system uptime of
+// the exporter to milliseconds since the epoch. The uptime may have wrapped
+// since the time was taken.
+func absoluteMilliseconds(hdr MessageHeader, uptime uint32) uint64 {
+	age := int64(int32(hdr.SysUptime - uptime))
+	return uint64(int64(hdr.ExportTime)*1000 - age)
+}
@@ -0,0 +1,141 @@
+package ipfix
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTranslate(t *testing.T) {
+	nfv9 := Message{
+		Header: MessageHeader{Version: 9, SysUptime: 100000, ExportTime: 1500000000, SequenceNumber: 7, DomainID: 3},
+		TemplateRecords: []TemplateRecord{{
+			TemplateID: 256,
+			FieldSpecifiers: []TemplateFieldSpecifier{
+				{FieldID: 8, Length: 4},  // IPV4_SRC_ADDR
+				{FieldID: 1, Length: 4},  // IN_BYTES
+				{FieldID: 22, Length: 4}, // FIRST_SWITCHED
+				{FieldID: 21, Length: 4}, // LAST_SWITCHED
+			},
+		}},
+		OptionsTemplateRecords: []OptionsTemplateRecord{{
+			TemplateID:      257,
+			ScopeFieldCount: 1,
+			FieldSpecifiers: []TemplateFieldSpecifier{
+				{FieldID: 1, Length: 4},  // System
+				{FieldID: 34, Length: 4}, // SAMPLING_INTERVAL
+			},
+		}},
+		DataRecords: []DataRecord{{
+			TemplateID: 256,
+			Fields:     [][]byte{{192, 0, 2, 1}, {0, 0, 4, 0}, {0, 1, 0x5f, 0x90}, {0, 1, 0x7e, 0xd0}}, // 90000 and 98000 ms
+		}},
+	}
+	bs, err := nfv9.Marshal()
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	s := NewSession()
+	msg, err := s.ParseBuffer(bs)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+
+	tr := NewTranslator(s)
+	m, err := tr.Translate(msg)
+	if err != nil {
+		t.Fatal("Translate failed", err)
+	}
+	if m.Header.Version != 10 || m.Header.DomainID != 3 || m.Header.ExportTime != 1500000000 || m.Header.SequenceNumber != 0 {
+		t.Errorf("Incorrect header %+v", m.Header)
+	}
+	if fs := m.OptionsTemplateRecords[0].FieldSpecifiers; fs[0].FieldID != 144 || fs[1].FieldID != 34 {
+		t.Error("Incorrect options template", fs)
+	}
+
+	// The IPFIX message decodes on its own
+	bs, err = m.Marshal()
+	if err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	p := NewSession()
+	m, err = p.ParseBuffer(bs)
+	if err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if len(m.DataRecords) != 1 {
+		t.Fatal("Incorrect number of data records", len(m.DataRecords))
+	}
+	fields := NewInterpreter(p).Interpret(m.DataRecords[0])
+	export := time.Unix(1500000000, 0)
+	for i, want := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"octetDeltaCount", uint64(1024)},
+		{"flowStartMilliseconds", export.Add(-10 * time.Second)},
+		{"flowEndMilliseconds", export.Add(-2 * time.Second)},
+	} {
+		f := fields[i+1]
+		if f.Name != want.name {
+			t.Errorf("Incorrect field %d name %q", i+1, f.Name)
+		}
+		if tm, ok := want.value.(time.Time); ok {
+			if v, _ := f.Value.(time.Time); !v.Equal(tm) {
+				t.Errorf("Incorrect %s %v", f.Name, f.Value)
+			}
+		} else if f.Value != want.value {
+			t.Errorf("Incorrect %s %v", f.Name, f.Value)
+		}
+	}
+
+	// A later message with only data records is encoded with the translated
+	// template
+	nfv9 = Message{Header: nfv9.Header, DataRecords: msg.DataRecords}
+	nfv9.Header.SequenceNumber++
+	if bs, err = s.Marshal(nfv9); err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	if msg, err = s.ParseBuffer(bs); err != nil {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	if m, err = tr.Translate(msg); err != nil {
+		t.Fatal("Translate failed", err)
+	}
+	if bs, err = tr.Marshal(m); err != nil {
+		t.Fatal("Marshal failed", err)
+	}
+	if m, err = p.ParseBuffer(bs); err != nil || len(m.DataRecords) != 1 {
+		t.Fatal("ParseBuffer failed", err)
+	}
+	fields = NewInterpreter(p).Interpret(m.DataRecords[0])
+	if v, _ := fields[3].Value.(time.Time); !v.Equal(export.Add(-2 * time.Second)) {
+		t.Errorf("Incorrect %s %v", fields[3].Name, fields[3].Value)
+	}
+}
+
+func TestTranslateSequence(t *testing.T) {
+	s := NewSession()
+	tr := NewTranslator(s)
+	if _, err := tr.Translate(Message{Header: MessageHeader{Version: 10}}); err != ErrVersion {
+		t.Error("Expected ErrVersion, got", err)
+	}
+	msg := Message{
+		Header:          MessageHeader{Version: 9, DomainID: 1},
+		TemplateRecords: []TemplateRecord{exportTemplate},
+	}
+	s.LoadTemplateRecords(msg.TemplateRecords)
+	msg.DataRecords = []DataRecord{exportRecord(1), exportRecord(2)}
+	for _, want := range []uint32{0, 2, 4} {
+		m, err := tr.Translate(msg)
+		if err != nil {
+			t.Fatal("Translate failed", err)
+		}
+		if m.Header.SequenceNumber != want {
+			t.Error("Incorrect sequence number", m.Header.SequenceNumber)
+		}
+	}
+	msg.DataRecords[0].TemplateID = 300
+	if _, err := tr.Translate(msg); err != ErrUnknownTemplate {
+		t.Error("Expected ErrUnknownTemplate, got", err)
+	}
+}
@@ -26,6 +26,7 @@ type Walker struct {
 	headerOnly bool
 	trbuf      []TemplateRecord
 	fidbuf     []TemplateFieldSpecifier
+	base       []byte // the buffer being walked, for error offsets
 }
 
 // NewWalker creates a new Walker object. It will use the given Filter
@@ -56,7 +57,7 @@ func (w *Walker) SetHeaderOnly(v bool) {
 	w.headerOnly = v
 }
 
-// WalkBuffer walks an IPFIX or Netflow V9 packet in buf, calling
+// WalkBuffer walks an IPFIX or Netflow (V9, V7, V5 or V1) packet in buf, calling
 // the callback function in accordance with the following rules:
 //
 // 1. If SetHeaderOnly(true) was called, the callback will be called
@@ -75,6 +76,9 @@ func (w *Walker) SetHeaderOnly(v bool) {
 // as in case #2 except that only those EID and FID combinations registered
 // with the Filter will trigger a callback. The EndOfRecord callback
 // will still occur as normal.
+//
+// Malformed packets are reported as a *ParseError describing where walking
+// stopped. Errors returned by the callback are passed through unchanged.
 func (w *Walker) WalkBuffer(buf []byte, cb RecordCallback) (err error) {
 	var r Record
 	if cb == nil {
@@ -82,6 +86,7 @@ func (w *Walker) WalkBuffer(buf []byte, cb RecordCallback) (err error) {
 		return
 	}
 	w.cb = cb
+	w.base = buf
 
 	sl := slice{bs: buf}
 	r.MessageHeader.unmarshal(&sl)
@@ -98,6 +103,8 @@ func (w *Walker) WalkBuffer(buf []byte, cb RecordCallback) (err error) {
 			err = w.walkIpfixBuffer(&sl, &r)
 		case nfv9Version:
 			err = w.walkNfv9Buffer(&sl, &r)
+		case netflowV1Version, netflowV5Version, netflowV7Version:
+			err = w.walkFixedBuffer(&sl, &r)
 		default:
 			err = ErrVersion
 		}
@@ -113,27 +120,30 @@ func (w *Walker) walkIpfixBuffer(sl *slice, r *Record) (err error) {
 	//reset our template filds buffer
 	w.fidbuf = w.fidbuf[0:0]
 
-	for {
+	for index := 0; ; index++ {
+		start := sl.bs
 		l := sl.Len()
 		if l == 0 {
 			break
 		} else if l < setHeaderLength {
-			err = io.ErrUnexpectedEOF
+			err = w.parseError(start, index, &setHeader{}, r, io.ErrUnexpectedEOF)
 			break
 		}
 		sh.unmarshal(sl)
 
 		if sh.Length < setHeaderLength {
-			err = io.ErrUnexpectedEOF
+			err = w.parseError(start, index, &sh, r, io.ErrUnexpectedEOF)
 			break
 		}
 		// Grab the bytes representing the set
 		setLen := int(sh.Length) - setHeaderLength
 		nsl.bs = sl.Cut(setLen)
 		if err = sl.Error(); err != nil {
+			err = w.parseError(start, index, &sh, r, err)
 			break
 		}
 		if err = w.walkIPFixSet(r, &sh, &nsl); err != nil {
+			err = w.parseError(start, index, &sh, r, err)
 			break
 		}
 		r.SetID++
@@ -203,23 +213,24 @@ func (w *Walker) handleDataRecord(r *Record, sh *setHeader, tpl []TemplateFieldS
 	r.EndOfRecord = false
 
 	for i := range tpl {
+		start := sl.bs
 		if l = int(tpl[i].Length); l == 0xffff {
 			if len(sl.bs) == 0 {
-				return ErrRead
+				return &fieldError{index: i, at: start, err: ErrRead}
 			}
 			if lo = sl.bs[0]; lo < 0xff {
 				l = int(lo)
 				sl.bs = sl.bs[1:]
 			} else {
 				if len(sl.bs) < 2 {
-					return ErrRead
+					return &fieldError{index: i, at: start, err: ErrRead}
 				}
 				l = int((uint16(sl.bs[0]) << 8) | uint16(sl.bs[1]))
 				sl.bs = sl.bs[2:]
 			}
 		}
 		if l > len(sl.bs) {
-			return ErrRead
+			return &fieldError{index: i, at: start, err: ErrRead}
 		}
 		val = sl.bs[:l]
 		sl.bs = sl.bs[l:]
@@ -305,26 +316,29 @@ func (w *Walker) walkNfv9Buffer(sl *slice, r *Record) (err error) {
 	//reset our template filds buffer
 	w.fidbuf = w.fidbuf[0:0]
 
-	for {
+	for index := 0; ; index++ {
+		start := sl.bs
 		l := sl.Len()
 		if l == 0 {
 			break
 		} else if l < setHeaderLength {
-			err = io.ErrUnexpectedEOF
+			err = w.parseError(start, index, &setHeader{}, r, io.ErrUnexpectedEOF)
 			break
 		}
 		sh.unmarshal(sl)
 		if sh.Length < setHeaderLength {
-			err = io.ErrUnexpectedEOF
+			err = w.parseError(start, index, &sh, r, io.ErrUnexpectedEOF)
 			break
 		}
 		// Grab the bytes representing the set
 		setLen := int(sh.Length) - setHeaderLength
 		nsl.bs = sl.Cut(setLen)
 		if err = sl.Error(); err != nil {
+			err = w.parseError(start, index, &sh, r, err)
 			break
 		}
 		if err = w.walkNFv9Set(r, &sh, &nsl); err != nil {
+			err = w.parseError(start, index, &sh, r, err)
 			break
 		}
 	}
@@ -333,6 +347,48 @@ func (w *Walker) walkNfv9Buffer(sl *slice, r *Record) (err error) {
 
 }
 
+// walkFixedBuffer walks the records of a fixed format Netflow packet, using
+// the well-known template for its version.
+func (w *Walker) walkFixedBuffer(sl *slice, r *Record) (err error) {
+	if err = sl.Error(); err != nil {
+		return newParseError(w.base, w.base, err)
+	}
+	tid := fixedFormats[r.Version].templateID
+	tpl := fixedTemplates[tid]
+	for r.DataRecordID = 0; r.DataRecordID < int(r.Length); r.DataRecordID++ {
+		if err = w.handleDataRecord(r, nil, tpl, sl); err != nil {
+			if fe, ok := err.(*fieldError); ok {
+				pe := newParseError(w.base, w.base, fe)
+				pe.TemplateID = tid
+				pe.RecordIndex = r.DataRecordID
+				err = pe
+			}
+			return
+		}
+	}
+	return
+}
+
+// parseError wraps an error found while walking the set starting at start in
+// a ParseError. Errors returned by the callback are passed through as is.
+func (w *Walker) parseError(start []byte, index int, sh *setHeader, r *Record, err error) error {
+	pe := newParseError(w.base, start, err)
+	switch err {
+	case ErrRead, ErrProtocol, io.ErrUnexpectedEOF:
+	case ErrUnknownTemplate:
+		pe.TemplateID = sh.SetID
+	default:
+		if _, ok := err.(*fieldError); !ok {
+			return err
+		}
+		pe.TemplateID = sh.SetID
+		pe.RecordIndex = r.DataRecordID
+	}
+	pe.SetIndex = index
+	pe.SetID = sh.SetID
+	return pe
+}
+
 func (w *Walker) walkNFv9Set(r *Record, sh *setHeader, sl *slice) (err error) {
 	var tmpl TemplateRecord
 	var ok bool